@@ -1,6 +1,9 @@
 package apperrors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrInvalidURLFormat  = errors.New("invalid URL format")
@@ -8,12 +11,52 @@ var (
 	ErrInvalidAppStoreID = errors.New("app store id should contain numbers only")
 
 	ErrDomainLinkNotAllowed = errors.New("domain link not in allow list")
-	ErrInvalidPathFormat    = errors.New("path must contain exactly one segment")
+	ErrFallbackNotAllowed   = errors.New("fallback link not in allow list")
+	ErrMixedScriptLinkHost  = errors.New("link host mixes scripts")
+	ErrInvalidFallbackLink  = errors.New("invalid fallback link")
+	ErrInvalidPathFormat    = errors.New("path must be a single segment, optionally under a registered path prefix")
+	ErrInvalidCustomPath    = errors.New("invalid custom path")
+	ErrInvalidSuffixOption  = errors.New("invalid suffix option")
+	ErrInvalidParamPolicy   = errors.New("invalid unrecognized param policy")
 	ErrInvalidRequestedLink = errors.New("invalid requested link")
+	ErrInvalidDurationDays  = errors.New("durationDays must be a positive number of days")
 
 	ErrInvalidFormat = errors.New("invalid request format")
 	ErrMissingHost   = errors.New("missing host")
 	ErrMissingLink   = errors.New("missing link")
 
 	ErrLinkNotFound = errors.New("link not found")
+	ErrPathConflict = errors.New("path already exists for host")
+
+	ErrPathAlreadyExists = errors.New("custom path already exists for host")
+
+	ErrNoAppsRegistered = errors.New("no apps registered for host")
+	ErrMissingAppID     = errors.New("bundleId or packageName is required")
+
+	ErrDomainNotRegistered = errors.New("host is not a registered short link domain")
+	ErrDomainAlreadyExists = errors.New("domain is already registered")
+
+	ErrInvalidDomainSettings   = errors.New("invalid domain settings")
+	ErrPathPrefixNotRegistered = errors.New("path prefix is not registered for host")
+
+	ErrAttributionNotFound = errors.New("attribution not found")
+
+	ErrInvalidConversionEvent  = errors.New("invalid conversion event name")
+	ErrInvalidConversionValue  = errors.New("conversion value needs a three-letter currency code")
+	ErrInvalidConversionTarget = errors.New("exactly one of shortLink or attributionId is required")
 )
+
+// ParamError ties an error to the long link parameter (e.g. "afl") that
+// caused it.
+type ParamError struct {
+	Param string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("param '%s': %v", e.Param, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
@@ -3,27 +3,67 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"dynamic-links-generator/api/apperrors"
 	"dynamic-links-generator/api/models"
 	"dynamic-links-generator/api/service"
+	"dynamic-links-generator/utils"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 )
 
 type Handler interface {
 	CreateLink(w http.ResponseWriter, r *http.Request)
 	ExchangeShortLink(w http.ResponseWriter, r *http.Request)
+	RedirectShortLink(w http.ResponseWriter, r *http.Request)
+	AppleAppSiteAssociation(w http.ResponseWriter, r *http.Request)
+	AssetLinks(w http.ResponseWriter, r *http.Request)
+	ListDomains(w http.ResponseWriter, r *http.Request)
+	RegisterDomain(w http.ResponseWriter, r *http.Request)
+	UpdateDomainSettings(w http.ResponseWriter, r *http.Request)
+	DeleteDomain(w http.ResponseWriter, r *http.Request)
+	LinkStats(w http.ResponseWriter, r *http.Request)
+	InstallAttribution(w http.ResponseWriter, r *http.Request)
+	ReopenAttribution(w http.ResponseWriter, r *http.Request)
+	UpdateClickFingerprint(w http.ResponseWriter, r *http.Request)
+	RecordConversion(w http.ResponseWriter, r *http.Request)
+	LinkConversionStats(w http.ResponseWriter, r *http.Request)
+	CampaignConversionStats(w http.ResponseWriter, r *http.Request)
 }
 
 type handler struct {
-	linkService service.LinkService
+	linkService        service.LinkService
+	appService         service.AppService
+	domainService      service.DomainService
+	statsService       service.StatsService
+	attributionService service.AttributionService
+	conversionService  service.ConversionService
+	events             service.EventRecorder
 }
 
-func NewHandler(linkService service.LinkService) Handler {
+func NewHandler(
+	linkService service.LinkService,
+	appService service.AppService,
+	domainService service.DomainService,
+	statsService service.StatsService,
+	attributionService service.AttributionService,
+	conversionService service.ConversionService,
+	events service.EventRecorder,
+) Handler {
 	return &handler{
-		linkService: linkService,
+		linkService:        linkService,
+		appService:         appService,
+		domainService:      domainService,
+		statsService:       statsService,
+		attributionService: attributionService,
+		conversionService:  conversionService,
+		events:             events,
 	}
 }
 
@@ -36,14 +76,20 @@ func (h *handler) CreateLink(w http.ResponseWriter, r *http.Request) {
 
 	createReq, err := h.linkService.PrepareDynamicLinkRequest(rawReq)
 	if err != nil {
+		var paramErr *apperrors.ParamError
 		switch {
+		case errors.Is(err, apperrors.ErrInvalidFallbackLink) && errors.As(err, &paramErr):
+			WriteErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("'%s' parameter has an invalid scheme, must be http or https", paramErr.Param), "INVALID_ARGUMENT")
 		case errors.Is(err, apperrors.ErrInvalidURLFormat):
 			WriteErrorResponse(w, http.StatusBadRequest, "longDynamicLink is not parsable", "INVALID_ARGUMENT")
 		case errors.Is(err, apperrors.ErrHostInvalid):
 			WriteErrorResponse(w, http.StatusBadRequest, "Host is invalid", "INVALID_ARGUMENT")
 		case errors.Is(err, apperrors.ErrInvalidFormat),
 			errors.Is(err, apperrors.ErrMissingHost),
-			errors.Is(err, apperrors.ErrMissingLink):
+			errors.Is(err, apperrors.ErrMissingLink),
+			errors.Is(err, apperrors.ErrInvalidCustomPath),
+			errors.Is(err, apperrors.ErrInvalidSuffixOption),
+			errors.Is(err, apperrors.ErrInvalidParamPolicy):
 			WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_ARGUMENT")
 		default:
 			WriteErrorResponse(w, http.StatusBadRequest, "Invalid request format", "INVALID_ARGUMENT")
@@ -52,9 +98,32 @@ func (h *handler) CreateLink(w http.ResponseWriter, r *http.Request) {
 	}
 
 	shortLinkResp, err := h.linkService.CreateDynamicLink(r.Context(), createReq)
+	var paramErr *apperrors.ParamError
 	if errors.Is(err, apperrors.ErrDomainLinkNotAllowed) {
 		WriteErrorResponse(w, http.StatusBadRequest, "'link' parameter contains a host that is not in the allow list", "INVALID_ARGUMENT")
 		return
+	} else if errors.Is(err, apperrors.ErrDomainNotRegistered) {
+		// The host may come from domainUriPrefix; it parsed, or the request
+		// would not have got this far.
+		host, _, _ := utils.SplitURLPrefix(service.LinkDomain(createReq.DynamicLinkInfo))
+		WriteErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Host '%s' is not a registered short link domain", host), "INVALID_ARGUMENT")
+		return
+	} else if errors.Is(err, apperrors.ErrPathPrefixNotRegistered) {
+		WriteErrorResponse(w, http.StatusBadRequest, "Path prefix is not registered for this host", "INVALID_ARGUMENT")
+		return
+	} else if errors.Is(err, apperrors.ErrMixedScriptLinkHost) {
+		WriteErrorResponse(w, http.StatusBadRequest, "'link' parameter contains a host that mixes characters from different scripts", "INVALID_ARGUMENT")
+		return
+	} else if errors.Is(err, apperrors.ErrFallbackNotAllowed) && errors.As(err, &paramErr) {
+		WriteErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("'%s' parameter contains a host that is not in the allow list", paramErr.Param), "INVALID_ARGUMENT")
+		return
+	} else if errors.Is(err, apperrors.ErrPathAlreadyExists) {
+		WriteErrorResponse(w, http.StatusConflict, "Requested custom path is already in use for this host", "ALREADY_EXISTS")
+		return
+	} else if errors.Is(err, apperrors.ErrInvalidCustomPath) ||
+		errors.Is(err, apperrors.ErrInvalidSuffixOption) {
+		WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_ARGUMENT")
+		return
 	} else if errors.Is(err, apperrors.ErrInvalidAppStoreID) {
 		WriteErrorResponse(w, http.StatusBadRequest, "'isbn' parameter contains a non-numeric value", "INVALID_ARGUMENT")
 		return
@@ -79,17 +148,432 @@ func (h *handler) ExchangeShortLink(w http.ResponseWriter, r *http.Request) {
 	switch {
 	case errors.Is(err, apperrors.ErrLinkNotFound):
 		WriteErrorResponse(w, http.StatusNotFound, "Link not found", "NOT_FOUND")
-	case errors.Is(err, apperrors.ErrInvalidRequestedLink):
+	case errors.Is(err, apperrors.ErrInvalidRequestedLink),
+		errors.Is(err, apperrors.ErrInvalidPathFormat),
+		errors.Is(err, apperrors.ErrPathPrefixNotRegistered):
 		WriteErrorResponse(w, http.StatusBadRequest, "Invalid requested link", "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrDomainNotRegistered):
+		WriteErrorResponse(w, http.StatusBadRequest, "Requested link host is not a registered short link domain", "INVALID_ARGUMENT")
 	case err != nil:
 		log.Error().Err(err).Msg("Failed to resolve short link")
 		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to resolve link", "INTERNAL")
 	default:
+		// The stored link parsed when it was created, so an error only
+		// leaves the campaign off the event.
+		parsed, _ := h.linkService.ParseLongDynamicLink(link.LongLink)
+		h.recordEvent(r, req.RequestedLink, models.EventTypeClick, models.EventSourceExchange,
+			utils.DetectPlatform(r.UserAgent()), parsed.DynamicLinkInfo.AnalyticsInfo.MarketingParameters)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(link)
 	}
 }
 
+func (h *handler) RedirectShortLink(w http.ResponseWriter, r *http.Request) {
+	info, err := h.linkService.ResolveDynamicLink(r.Context(), requestedLink(r))
+	switch {
+	case errors.Is(err, apperrors.ErrLinkNotFound),
+		errors.Is(err, apperrors.ErrDomainNotRegistered),
+		errors.Is(err, apperrors.ErrPathPrefixNotRegistered):
+		WriteErrorResponse(w, http.StatusNotFound, "Link not found", "NOT_FOUND")
+		return
+	case errors.Is(err, apperrors.ErrInvalidRequestedLink),
+		errors.Is(err, apperrors.ErrInvalidPathFormat):
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid requested link", "INVALID_ARGUMENT")
+		return
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to resolve short link")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to resolve link", "INTERNAL")
+		return
+	}
+
+	platform := utils.DetectPlatform(r.UserAgent())
+
+	if service.IsPreviewHost(r.Host) {
+		h.recordEvent(r, requestedLink(r), models.EventTypeClick, models.EventSourcePreview, platform, info.AnalyticsInfo.MarketingParameters)
+		h.renderPreview(w, requestedLink(r), *info)
+		return
+	}
+
+	if r.URL.Query().Get("d") == "1" {
+		h.renderDebug(w, requestedLink(r), *info)
+		return
+	}
+
+	h.recordEvent(r, requestedLink(r), models.EventTypeClick, models.EventSourceRedirect, platform, info.AnalyticsInfo.MarketingParameters)
+
+	target := service.RedirectTarget(*info, platform)
+
+	if utils.IsCrawler(r.UserAgent()) {
+		h.renderSocial(w, requestedLink(r), target, *info)
+		return
+	}
+
+	clickID := h.recordFingerprint(r, *info, platform)
+
+	// The app preview page sends the visitor on to the same target, so it
+	// counts as a redirect too.
+	h.recordEvent(r, requestedLink(r), models.EventTypeRedirect, models.EventSourceRedirect, platform, info.AnalyticsInfo.MarketingParameters)
+
+	if service.ShowsAppPreview(*info, platform) {
+		h.renderAppPreview(w, target, clickID, *info)
+		return
+	}
+
+	log.Debug().
+		Str("platform", string(platform)).
+		Str("target", target).
+		Msg("Redirecting short link")
+
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (h *handler) AppleAppSiteAssociation(w http.ResponseWriter, r *http.Request) {
+	aasa, err := h.appService.AppleAppSiteAssociation(r.Context(), r.Host)
+	switch {
+	case errors.Is(err, apperrors.ErrNoAppsRegistered):
+		WriteErrorResponse(w, http.StatusNotFound, "No iOS apps registered for host", "NOT_FOUND")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to build apple-app-site-association")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to load apps", "INTERNAL")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(aasa)
+	}
+}
+
+func (h *handler) AssetLinks(w http.ResponseWriter, r *http.Request) {
+	links, err := h.appService.AssetLinks(r.Context(), r.Host)
+	switch {
+	case errors.Is(err, apperrors.ErrNoAppsRegistered):
+		WriteErrorResponse(w, http.StatusNotFound, "No Android apps registered for host", "NOT_FOUND")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to build assetlinks.json")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to load apps", "INTERNAL")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(links)
+	}
+}
+
+func (h *handler) ListDomains(w http.ResponseWriter, r *http.Request) {
+	domains, err := h.domainService.ListDomains(r.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list domains")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to list domains", "INTERNAL")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.DomainsResponse{Domains: domains})
+}
+
+func (h *handler) RegisterDomain(w http.ResponseWriter, r *http.Request) {
+	var req models.RegisterDomainRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Host == "" {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid or missing host", "INVALID_ARGUMENT")
+		return
+	}
+
+	domain, err := h.domainService.RegisterDomain(r.Context(), req.Host, req.Settings)
+	switch {
+	case errors.Is(err, apperrors.ErrHostInvalid),
+		errors.Is(err, apperrors.ErrInvalidDomainSettings):
+		WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrDomainAlreadyExists):
+		WriteErrorResponse(w, http.StatusConflict, "Domain is already registered", "ALREADY_EXISTS")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to register domain")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to register domain", "INTERNAL")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(domain)
+	}
+}
+
+func (h *handler) UpdateDomainSettings(w http.ResponseWriter, r *http.Request) {
+	var settings models.DomainSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_ARGUMENT")
+		return
+	}
+
+	domain, err := h.domainService.UpdateDomainSettings(r.Context(), chi.URLParam(r, "host"), settings)
+	switch {
+	case errors.Is(err, apperrors.ErrHostInvalid),
+		errors.Is(err, apperrors.ErrInvalidDomainSettings):
+		WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrDomainNotRegistered):
+		WriteErrorResponse(w, http.StatusNotFound, "Domain not found", "NOT_FOUND")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to update domain settings")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update domain settings", "INTERNAL")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(domain)
+	}
+}
+
+func (h *handler) DeleteDomain(w http.ResponseWriter, r *http.Request) {
+	err := h.domainService.DeleteDomain(r.Context(), chi.URLParam(r, "host"))
+	switch {
+	case errors.Is(err, apperrors.ErrHostInvalid):
+		WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrDomainNotRegistered):
+		WriteErrorResponse(w, http.StatusNotFound, "Domain not found", "NOT_FOUND")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to delete domain")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to delete domain", "INTERNAL")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *handler) LinkStats(w http.ResponseWriter, r *http.Request) {
+	link, err := url.PathUnescape(chi.URLParam(r, "shortDynamicLink"))
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid short dynamic link", "INVALID_ARGUMENT")
+		return
+	}
+
+	durationDays, err := strconv.Atoi(r.URL.Query().Get("durationDays"))
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid or missing durationDays", "INVALID_ARGUMENT")
+		return
+	}
+
+	stats, err := h.statsService.LinkStats(r.Context(), link, durationDays)
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidDurationDays):
+		WriteErrorResponse(w, http.StatusBadRequest, "durationDays must be a positive number", "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrLinkNotFound):
+		WriteErrorResponse(w, http.StatusNotFound, "Link not found", "NOT_FOUND")
+	case errors.Is(err, apperrors.ErrInvalidRequestedLink),
+		errors.Is(err, apperrors.ErrInvalidPathFormat),
+		errors.Is(err, apperrors.ErrPathPrefixNotRegistered):
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid short dynamic link", "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrDomainNotRegistered):
+		WriteErrorResponse(w, http.StatusBadRequest, "Short dynamic link host is not a registered short link domain", "INVALID_ARGUMENT")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to load link stats")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to load link stats", "INTERNAL")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	}
+}
+
+func (h *handler) InstallAttribution(w http.ResponseWriter, r *http.Request) {
+	var req models.InstallAttributionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_ARGUMENT")
+		return
+	}
+
+	resp, err := h.attributionService.InstallAttribution(r.Context(), req, utils.AnonymizeIP(r.RemoteAddr))
+	switch {
+	case errors.Is(err, apperrors.ErrMissingAppID):
+		WriteErrorResponse(w, http.StatusBadRequest, "bundleId or packageName is required", "INVALID_ARGUMENT")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to attribute install")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to attribute install", "INTERNAL")
+	default:
+		if resp.RequestedLink != "" {
+			platform, _ := service.AppPlatform(req.BundleID, req.PackageName)
+			h.recordEvent(r, resp.RequestedLink, models.EventTypeAppInstall, models.EventSourceAttribution, platform, resp.MarketingParameters())
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func (h *handler) ReopenAttribution(w http.ResponseWriter, r *http.Request) {
+	var req models.ReopenAttributionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RequestedLink == "" {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid or missing requestedLink", "INVALID_ARGUMENT")
+		return
+	}
+
+	resp, err := h.attributionService.ReopenAttribution(r.Context(), req)
+	switch {
+	case errors.Is(err, apperrors.ErrLinkNotFound):
+		WriteErrorResponse(w, http.StatusNotFound, "Link not found", "NOT_FOUND")
+	case errors.Is(err, apperrors.ErrInvalidRequestedLink),
+		errors.Is(err, apperrors.ErrInvalidPathFormat),
+		errors.Is(err, apperrors.ErrPathPrefixNotRegistered):
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid requested link", "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrDomainNotRegistered):
+		WriteErrorResponse(w, http.StatusBadRequest, "Requested link host is not a registered short link domain", "INVALID_ARGUMENT")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to attribute reopen")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to resolve link", "INTERNAL")
+	default:
+		platform, _ := service.AppPlatform(req.BundleID, req.PackageName)
+		h.recordEvent(r, req.RequestedLink, models.EventTypeAppReOpen, models.EventSourceAttribution, platform, resp.MarketingParameters())
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// UpdateClickFingerprint receives the browser-only signals (timezone,
+// screen) the app preview page reads for a recorded click.
+func (h *handler) UpdateClickFingerprint(w http.ResponseWriter, r *http.Request) {
+	var update models.ClickFingerprintUpdate
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&update); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_ARGUMENT")
+		return
+	}
+
+	err := h.attributionService.UpdateClick(r.Context(), chi.URLParam(r, "id"), update)
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidFormat):
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid click fingerprint", "INVALID_ARGUMENT")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to update click fingerprint")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update click fingerprint", "INTERNAL")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *handler) RecordConversion(w http.ResponseWriter, r *http.Request) {
+	var req models.ConversionEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body", "INVALID_ARGUMENT")
+		return
+	}
+
+	err := h.conversionService.RecordConversion(r.Context(), req)
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidConversionEvent):
+		WriteErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid event '%s'", req.Event), "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrInvalidConversionValue),
+		errors.Is(err, apperrors.ErrInvalidConversionTarget):
+		WriteErrorResponse(w, http.StatusBadRequest, err.Error(), "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrAttributionNotFound):
+		WriteErrorResponse(w, http.StatusNotFound, "Attribution not found", "NOT_FOUND")
+	case errors.Is(err, apperrors.ErrLinkNotFound):
+		WriteErrorResponse(w, http.StatusNotFound, "Link not found", "NOT_FOUND")
+	case errors.Is(err, apperrors.ErrInvalidRequestedLink),
+		errors.Is(err, apperrors.ErrInvalidPathFormat),
+		errors.Is(err, apperrors.ErrPathPrefixNotRegistered),
+		errors.Is(err, apperrors.ErrDomainNotRegistered):
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid short link", "INVALID_ARGUMENT")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to record conversion")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to record conversion", "INTERNAL")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *handler) LinkConversionStats(w http.ResponseWriter, r *http.Request) {
+	link, err := url.PathUnescape(chi.URLParam(r, "shortDynamicLink"))
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid short dynamic link", "INVALID_ARGUMENT")
+		return
+	}
+	durationDays, err := strconv.Atoi(r.URL.Query().Get("durationDays"))
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid or missing durationDays", "INVALID_ARGUMENT")
+		return
+	}
+
+	stats, err := h.conversionService.LinkConversionStats(r.Context(), link, durationDays)
+	h.writeConversionStats(w, stats, err)
+}
+
+func (h *handler) CampaignConversionStats(w http.ResponseWriter, r *http.Request) {
+	campaign, err := url.PathUnescape(chi.URLParam(r, "campaign"))
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid campaign", "INVALID_ARGUMENT")
+		return
+	}
+	durationDays, err := strconv.Atoi(r.URL.Query().Get("durationDays"))
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid or missing durationDays", "INVALID_ARGUMENT")
+		return
+	}
+
+	stats, err := h.conversionService.CampaignConversionStats(r.Context(), campaign, durationDays)
+	h.writeConversionStats(w, stats, err)
+}
+
+func (h *handler) writeConversionStats(w http.ResponseWriter, stats *models.ConversionStatsResponse, err error) {
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidDurationDays):
+		WriteErrorResponse(w, http.StatusBadRequest, "durationDays must be a positive number", "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrInvalidFormat):
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid campaign", "INVALID_ARGUMENT")
+	case errors.Is(err, apperrors.ErrLinkNotFound):
+		WriteErrorResponse(w, http.StatusNotFound, "Link not found", "NOT_FOUND")
+	case errors.Is(err, apperrors.ErrInvalidRequestedLink),
+		errors.Is(err, apperrors.ErrInvalidPathFormat),
+		errors.Is(err, apperrors.ErrPathPrefixNotRegistered),
+		errors.Is(err, apperrors.ErrDomainNotRegistered):
+		WriteErrorResponse(w, http.StatusBadRequest, "Invalid short dynamic link", "INVALID_ARGUMENT")
+	case err != nil:
+		log.Error().Err(err).Msg("Failed to load conversion stats")
+		WriteErrorResponse(w, http.StatusInternalServerError, "Failed to load conversion stats", "INTERNAL")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stats)
+	}
+}
+
+// recordFingerprint keeps what the request tells about the clicking device
+// for install attribution, and returns the click id ("" when not recorded).
+// A failure only costs the attribution, so the redirect goes on.
+func (h *handler) recordFingerprint(r *http.Request, info models.DynamicLinkInfo, platform utils.Platform) string {
+	model, osVersion := utils.DeviceInfo(r.UserAgent())
+	id, err := h.attributionService.RecordClick(r.Context(), info, models.ClickFingerprint{
+		RequestedLink: requestedLink(r),
+		Platform:      string(platform),
+		IPPrefix:      utils.AnonymizeIP(r.RemoteAddr),
+		DeviceModel:   model,
+		OsVersion:     osVersion,
+		Language:      utils.PrimaryLanguage(r.Header.Get("Accept-Language")),
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to record click fingerprint")
+		return ""
+	}
+	return id
+}
+
+// recordEvent hands an event on link to the event pipeline. It runs after the
+// link resolved, so the key is known to be valid.
+func (h *handler) recordEvent(
+	r *http.Request,
+	link, eventType, source string,
+	platform utils.Platform,
+	utm models.MarketingParameters,
+) {
+	host, path, err := service.ShortLinkKey(link)
+	if err != nil {
+		return
+	}
+
+	h.events.Record(models.LinkEvent{
+		Host:           host,
+		Path:           path,
+		EventType:      eventType,
+		Source:         source,
+		Platform:       string(platform),
+		Referrer:       r.Referer(),
+		IPPrefix:       utils.AnonymizeIP(r.RemoteAddr),
+		UserAgentClass: utils.UserAgentClass(r.UserAgent()),
+		UtmSource:      utm.UtmSource,
+		UtmMedium:      utm.UtmMedium,
+		UtmCampaign:    utm.UtmCampaign,
+		OccurredAt:     time.Now().UTC(),
+	})
+}
+
+func requestedLink(r *http.Request) string {
+	return "https://" + r.Host + r.URL.EscapedPath()
+}
+
 func WriteErrorResponse(w http.ResponseWriter, code int, message string, status string) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RequireAdminKey guards the admin API with a bearer token. The admin API is
+// disabled when no key is configured.
+func RequireAdminKey(key string) func(http.Handler) http.Handler {
+	return RequireAPIKey("Admin", key)
+}
+
+// RequireAPIKey guards the routes of the named API with a bearer token. The
+// API is disabled when no key is configured.
+func RequireAPIKey(name, key string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key == "" {
+				WriteErrorResponse(w, http.StatusForbidden, fmt.Sprintf("%s API is disabled", name), "PERMISSION_DENIED")
+				return
+			}
+
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(key)) != 1 {
+				WriteErrorResponse(w, http.StatusUnauthorized,
+					fmt.Sprintf("Missing or invalid %s API key", strings.ToLower(name)), "UNAUTHENTICATED")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
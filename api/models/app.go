@@ -0,0 +1,43 @@
+package models
+
+type IosApp struct {
+	TeamID   string `json:"teamId"`
+	BundleID string `json:"bundleId"`
+}
+
+type AndroidApp struct {
+	PackageName            string   `json:"packageName"`
+	SHA256CertFingerprints []string `json:"sha256CertFingerprints"`
+}
+
+type AppleAppSiteAssociation struct {
+	AppLinks AppLinks `json:"applinks"`
+}
+
+type AppLinks struct {
+	Apps    []string        `json:"apps"`
+	Details []AppLinkDetail `json:"details"`
+}
+
+type AppLinkDetail struct {
+	AppIDs     []string           `json:"appIDs"`
+	Components []AppLinkComponent `json:"components"`
+	AppID      string             `json:"appID"`
+	Paths      []string           `json:"paths"`
+}
+
+type AppLinkComponent struct {
+	Path    string `json:"/"`
+	Exclude bool   `json:"exclude,omitempty"`
+}
+
+type AssetLink struct {
+	Relation []string        `json:"relation"`
+	Target   AssetLinkTarget `json:"target"`
+}
+
+type AssetLinkTarget struct {
+	Namespace              string   `json:"namespace"`
+	PackageName            string   `json:"package_name"`
+	SHA256CertFingerprints []string `json:"sha256_cert_fingerprints"`
+}
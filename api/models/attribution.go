@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// How sure an attribution match is, using Firebase's confidence names.
+const (
+	AttributionConfidenceUnique  = "UNIQUE"  // the app supplied the link itself
+	AttributionConfidenceDefault = "DEFAULT" // IP prefix and device signals agree
+	AttributionConfidenceWeak    = "WEAK"    // little beyond the IP prefix agrees
+)
+
+// ClickFingerprint is what is known about the device behind a click, kept so
+// the app can claim the link after it is installed.
+type ClickFingerprint struct {
+	ID            string
+	RequestedLink string
+	Host          string
+	Path          string
+	AppID         string // package name or bundle id the click was sent to
+	Platform      string
+	IPPrefix      string
+	DeviceModel   string
+	OsVersion     string
+	Language      string
+	Timezone      string
+	ScreenWidth   int
+	ScreenHeight  int
+	ClickedAt     time.Time
+}
+
+type DeviceFingerprint struct {
+	DeviceModelName        string `json:"deviceModelName,omitempty"`
+	LanguageCode           string `json:"languageCode,omitempty"`
+	Timezone               string `json:"timezone,omitempty"`
+	ScreenResolutionWidth  int    `json:"screenResolutionWidth,omitempty"`
+	ScreenResolutionHeight int    `json:"screenResolutionHeight,omitempty"`
+}
+
+type InstallAttributionRequest struct {
+	BundleID               string            `json:"bundleId,omitempty"`
+	PackageName            string            `json:"packageName,omitempty"`
+	Device                 DeviceFingerprint `json:"device"`
+	IosVersion             string            `json:"iosVersion,omitempty"`
+	OsVersion              string            `json:"osVersion,omitempty"` // for non-iOS apps
+	SdkVersion             string            `json:"sdkVersion,omitempty"`
+	UniqueMatchLinkToCheck string            `json:"uniqueMatchLinkToCheck,omitempty"`
+}
+
+type ReopenAttributionRequest struct {
+	RequestedLink string `json:"requestedLink"`
+	BundleID      string `json:"bundleId,omitempty"`
+	PackageName   string `json:"packageName,omitempty"`
+	SdkVersion    string `json:"sdkVersion,omitempty"`
+}
+
+type AttributionResponse struct {
+	DeepLink              string `json:"deepLink,omitempty"`
+	RequestedLink         string `json:"requestedLink,omitempty"`
+	AttributionID         string `json:"attributionId,omitempty"`
+	AttributionConfidence string `json:"attributionConfidence,omitempty"`
+	AppMinimumVersion     string `json:"appMinimumVersion,omitempty"`
+	UtmSource             string `json:"utmSource,omitempty"`
+	UtmMedium             string `json:"utmMedium,omitempty"`
+	UtmCampaign           string `json:"utmCampaign,omitempty"`
+	UtmTerm               string `json:"utmTerm,omitempty"`
+	UtmContent            string `json:"utmContent,omitempty"`
+	MatchMessage          string `json:"matchMessage,omitempty"`
+}
+
+func (r AttributionResponse) MarketingParameters() MarketingParameters {
+	return MarketingParameters{
+		UtmSource:   r.UtmSource,
+		UtmMedium:   r.UtmMedium,
+		UtmCampaign: r.UtmCampaign,
+		UtmTerm:     r.UtmTerm,
+		UtmContent:  r.UtmContent,
+	}
+}
+
+// ClickFingerprintUpdate carries the signals only the browser can read,
+// posted by the app preview page.
+type ClickFingerprintUpdate struct {
+	Timezone     string `json:"timezone"`
+	ScreenWidth  int    `json:"screenWidth"`
+	ScreenHeight int    `json:"screenHeight"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// ConversionEvent is an app-reported event credited to the short link a user
+// came from, with the link's marketing parameters copied in so reports do not
+// have to re-parse links.
+type ConversionEvent struct {
+	Host          string
+	Path          string
+	AttributionID string
+	EventName     string
+	Value         *float64
+	Currency      string
+	Platform      string
+	Utm           MarketingParameters
+	OccurredAt    time.Time
+}
+
+// ConversionEventRequest references the link either directly (shortLink) or
+// through the attributionId returned by installAttribution.
+type ConversionEventRequest struct {
+	Event         string     `json:"event"`
+	ShortLink     string     `json:"shortLink,omitempty"`
+	AttributionID string     `json:"attributionId,omitempty"`
+	Value         *float64   `json:"value,omitempty"`
+	Currency      string     `json:"currency,omitempty"`
+	Platform      string     `json:"platform,omitempty"`
+	OccurredAt    *time.Time `json:"occurredAt,omitempty"`
+}
+
+// ConversionTotal sums one event name in one currency.
+type ConversionTotal struct {
+	EventName string
+	Currency  string
+	Count     int64
+	Value     float64
+}
+
+type ConversionStatsResponse struct {
+	Clicks      int64            `json:"clicks"`
+	Conversions []ConversionStat `json:"conversions"`
+}
+
+type ConversionStat struct {
+	Event          string          `json:"event"`
+	Count          int64           `json:"count"`
+	ConversionRate float64         `json:"conversionRate"` // count / clicks
+	Values         []CurrencyValue `json:"values,omitempty"`
+}
+
+type CurrencyValue struct {
+	Currency string  `json:"currency"`
+	Total    float64 `json:"total"`
+}
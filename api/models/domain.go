@@ -0,0 +1,146 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"dynamic-links-generator/utils"
+)
+
+type Domain struct {
+	Host      string         `json:"host"`
+	Settings  DomainSettings `json:"settings"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// DomainSettings overrides the global configuration for a single short link
+// host. Zero values fall back to the global setting.
+type DomainSettings struct {
+	ShortPathLength       int                  `json:"shortPathLength,omitempty"`
+	UnguessablePathLength int                  `json:"unguessablePathLength,omitempty"`
+	PathAlphabet          string               `json:"pathAlphabet,omitempty"`
+	URLScheme             string               `json:"urlScheme,omitempty"`
+	DomainAllowList       []string             `json:"domainAllowList,omitempty"`
+	DefaultSuffixOption   string               `json:"defaultSuffixOption,omitempty"`
+	DefaultAppParameters  DefaultAppParameters `json:"defaultAppParameters,omitempty"`
+	PathPrefixes          []string             `json:"pathPrefixes,omitempty"` // e.g. "links" for https://example.com/links/abc123
+}
+
+type DefaultAppParameters struct {
+	AndroidPackageName string `json:"androidPackageName,omitempty"`
+	IosBundleId        string `json:"iosBundleId,omitempty"`
+	IosAppStoreId      string `json:"iosAppStoreId,omitempty"`
+}
+
+// Merge returns s with every non-zero field of override applied on top.
+func (s DomainSettings) Merge(override DomainSettings) DomainSettings {
+	if override.ShortPathLength > 0 {
+		s.ShortPathLength = override.ShortPathLength
+	}
+	if override.UnguessablePathLength > 0 {
+		s.UnguessablePathLength = override.UnguessablePathLength
+	}
+	if override.PathAlphabet != "" {
+		s.PathAlphabet = override.PathAlphabet
+	}
+	if override.URLScheme != "" {
+		s.URLScheme = override.URLScheme
+	}
+	if len(override.DomainAllowList) > 0 {
+		s.DomainAllowList = override.DomainAllowList
+	}
+	if override.DefaultSuffixOption != "" {
+		s.DefaultSuffixOption = override.DefaultSuffixOption
+	}
+	if apn := override.DefaultAppParameters.AndroidPackageName; apn != "" {
+		s.DefaultAppParameters.AndroidPackageName = apn
+	}
+	if ibi := override.DefaultAppParameters.IosBundleId; ibi != "" {
+		s.DefaultAppParameters.IosBundleId = ibi
+	}
+	if isi := override.DefaultAppParameters.IosAppStoreId; isi != "" {
+		s.DefaultAppParameters.IosAppStoreId = isi
+	}
+	if len(override.PathPrefixes) > 0 {
+		s.PathPrefixes = override.PathPrefixes
+	}
+	return s
+}
+
+// Normalize strips the slashes users tend to wrap path prefixes in.
+func (s DomainSettings) Normalize() DomainSettings {
+	if len(s.PathPrefixes) == 0 {
+		return s
+	}
+	prefixes := make([]string, 0, len(s.PathPrefixes))
+	for _, prefix := range s.PathPrefixes {
+		prefixes = append(prefixes, strings.Trim(prefix, "/"))
+	}
+	s.PathPrefixes = prefixes
+	return s
+}
+
+func (s DomainSettings) Validate() error {
+	if s.ShortPathLength < 0 || s.UnguessablePathLength < 0 {
+		return fmt.Errorf("path lengths must not be negative")
+	}
+	if s.PathAlphabet != "" {
+		if err := utils.ValidatePathAlphabet(s.PathAlphabet); err != nil {
+			return err
+		}
+	}
+	if s.URLScheme != "" && s.URLScheme != "http" && s.URLScheme != "https" {
+		return fmt.Errorf("url scheme must be http or https, got %q", s.URLScheme)
+	}
+	if s.DefaultSuffixOption != "" && !IsValidDefaultSuffixOption(s.DefaultSuffixOption) {
+		return fmt.Errorf("invalid default suffix option %q", s.DefaultSuffixOption)
+	}
+	if isi := s.DefaultAppParameters.IosAppStoreId; isi != "" && !utils.IsNumericString(isi) {
+		return fmt.Errorf("default iosAppStoreId must be numeric")
+	}
+	for _, prefix := range s.PathPrefixes {
+		if err := utils.ValidatePathPrefix(prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateOverride validates s as applied on top of base, the settings the
+// host would otherwise get. Path lengths are checked on the result when s
+// changes them, so a host without such overrides keeps the global ones as
+// they are.
+func (s DomainSettings) ValidateOverride(base DomainSettings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if s.ShortPathLength == 0 && s.UnguessablePathLength == 0 && s.PathAlphabet == "" {
+		return nil
+	}
+	return base.Merge(s).ValidatePathLengths()
+}
+
+// ValidatePathLengths checks that resolved settings generate SHORT paths long
+// enough and UNGUESSABLE paths with enough entropy.
+func (s DomainSettings) ValidatePathLengths() error {
+	if err := utils.ValidateShortPathLength(s.ShortPathLength); err != nil {
+		return err
+	}
+	if err := utils.ValidateUnguessablePathLength(s.UnguessablePathLength, s.PathAlphabet); err != nil {
+		return err
+	}
+	if s.UnguessablePathLength < s.ShortPathLength {
+		return fmt.Errorf("unguessable path length %d is shorter than the short path length %d", s.UnguessablePathLength, s.ShortPathLength)
+	}
+	return nil
+}
+
+type RegisterDomainRequest struct {
+	Host     string         `json:"host"`
+	Settings DomainSettings `json:"settings"`
+}
+
+type DomainsResponse struct {
+	Domains []Domain `json:"domains"`
+}
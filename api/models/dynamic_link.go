@@ -1,13 +1,18 @@
 package models
 
+import "net/url"
+
 type DynamicLinkInfo struct {
 	Host                    string                  `json:"host"`
+	DomainUriPrefix         string                  `json:"domainUriPrefix,omitempty"` // host with an optional path prefix, e.g. https://example.com/links
 	Link                    string                  `json:"link"`
 	AndroidParameters       AndroidParameters       `json:"androidParameters,omitempty"`
 	IosParameters           IosParameters           `json:"iosParameters,omitempty"`
 	OtherPlatformParameters OtherPlatformParameters `json:"otherPlatformParameters,omitempty"`
 	AnalyticsInfo           AnalyticsInfo           `json:"analyticsInfo,omitempty"`
 	SocialMetaTagInfo       SocialMetaTagInfo       `json:"socialMetaTagInfo,omitempty"`
+	NavigationInfo          NavigationInfo          `json:"navigationInfo,omitempty"`
+	UnrecognizedParams      url.Values              `json:"-"` // long link params with no field above
 }
 
 type AndroidParameters struct {
@@ -17,9 +22,13 @@ type AndroidParameters struct {
 }
 
 type IosParameters struct {
+	IosBundleId         string `json:"iosBundleId,omitempty"`
 	IosFallbackLink     string `json:"iosFallbackLink,omitempty"`
+	IosCustomScheme     string `json:"iosCustomScheme,omitempty"`
 	IosIpadFallbackLink string `json:"iosIpadFallbackLink,omitempty"`
+	IosIpadBundleId     string `json:"iosIpadBundleId,omitempty"`
 	IosAppStoreId       string `json:"iosAppStoreId,omitempty"`
+	IosMinimumVersion   string `json:"iosMinimumVersion,omitempty"`
 }
 
 type OtherPlatformParameters struct {
@@ -52,6 +61,40 @@ type SocialMetaTagInfo struct {
 	SocialImageLink   string `json:"socialImageLink,omitempty"`
 }
 
+type NavigationInfo struct {
+	EnableForcedRedirect bool `json:"enableForcedRedirect,omitempty"`
+}
+
+const (
+	SuffixOptionShort       = "SHORT"
+	SuffixOptionUnguessable = "UNGUESSABLE"
+	SuffixOptionCustom      = "CUSTOM"
+)
+
+func IsValidSuffixOption(option string) bool {
+	switch option {
+	case SuffixOptionShort, SuffixOptionUnguessable, SuffixOptionCustom:
+		return true
+	}
+	return false
+}
+
+// IsValidDefaultSuffixOption reports whether option can apply to requests
+// that omit suffix.option. CUSTOM cannot, since it needs a customPath.
+func IsValidDefaultSuffixOption(option string) bool {
+	return option == SuffixOptionShort || option == SuffixOptionUnguessable
+}
+
+const (
+	UnrecognizedParamsWarn        = "WARN"
+	UnrecognizedParamsPassThrough = "PASS_THROUGH"
+)
+
+func IsValidUnrecognizedParamPolicy(policy string) bool {
+	return policy == UnrecognizedParamsWarn || policy == UnrecognizedParamsPassThrough
+}
+
 type Suffix struct {
-	Option string `json:"option,omitempty"` // "SHORT" or "UNGUESSABLE"
+	Option     string `json:"option,omitempty"`     // "SHORT", "UNGUESSABLE" or "CUSTOM"
+	CustomPath string `json:"customPath,omitempty"` // required when Option is "CUSTOM"
 }
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// Event types, named as in Firebase's linkStats API.
+const (
+	EventTypeClick        = "CLICK"
+	EventTypeRedirect     = "REDIRECT"
+	EventTypeAppInstall   = "APP_INSTALL"
+	EventTypeAppFirstOpen = "APP_FIRST_OPEN"
+	EventTypeAppReOpen    = "APP_RE_OPEN"
+)
+
+var EventTypes = []string{
+	EventTypeClick,
+	EventTypeRedirect,
+	EventTypeAppInstall,
+	EventTypeAppFirstOpen,
+	EventTypeAppReOpen,
+}
+
+// Where a link was resolved from.
+const (
+	EventSourceRedirect    = "REDIRECT"
+	EventSourceExchange    = "EXCHANGE"
+	EventSourcePreview     = "PREVIEW"
+	EventSourceAttribution = "ATTRIBUTION"
+	EventSourceConversion  = "CONVERSION"
+)
+
+type LinkEvent struct {
+	Host           string
+	Path           string
+	EventType      string
+	Source         string
+	Platform       string
+	Referrer       string
+	IPPrefix       string // client IP truncated by utils.AnonymizeIP
+	UserAgentClass string
+	UtmSource      string
+	UtmMedium      string
+	UtmCampaign    string
+	OccurredAt     time.Time
+}
+
+// EventCount is the number of events of one type seen on one platform.
+type EventCount struct {
+	Platform  string
+	EventType string
+	Count     int64
+}
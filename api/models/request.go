@@ -9,6 +9,8 @@ type ExchangeShortLinkRequest struct {
 }
 
 type CreateDynamicLinkRequest struct {
-	DynamicLinkInfo DynamicLinkInfo `json:"dynamicLinkInfo"`
-	Suffix          Suffix          `json:"suffix,omitempty"`
+	DynamicLinkInfo          DynamicLinkInfo `json:"dynamicLinkInfo"`
+	Suffix                   Suffix          `json:"suffix,omitempty"`
+	UnrecognizedParamPolicy  string          `json:"unrecognizedParamPolicy,omitempty"`  // "WARN" or "PASS_THROUGH"
+	SkipDefaultAppParameters bool            `json:"skipDefaultAppParameters,omitempty"` // ignore the host's default apn, ibi and isi
 }
@@ -13,3 +13,15 @@ type LinkResponse struct {
 	ShortLink   string `json:"shortLink"`
 	PreviewLink string `json:"previewLink,omitempty"`
 }
+
+type LinkStatsResponse struct {
+	LinkEventStats []LinkEventStat `json:"linkEventStats"`
+}
+
+// LinkEventStat mirrors Firebase's DynamicLinkEventStat, which encodes the
+// count as a string.
+type LinkEventStat struct {
+	Platform string `json:"platform"`
+	Count    int64  `json:"count,string"`
+	Event    string `json:"event"`
+}
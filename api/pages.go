@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/api/service"
+	"dynamic-links-generator/api/views"
+	"dynamic-links-generator/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (h *handler) renderSocial(w http.ResponseWriter, shortLink, target string, info models.DynamicLinkInfo) {
+	social := info.SocialMetaTagInfo
+	page := views.SocialPage{
+		ShortLink:   shortLink,
+		Title:       social.SocialTitle,
+		Description: social.SocialDescription,
+		ImageLink:   social.SocialImageLink,
+		RedirectURL: target,
+	}
+	if page.Title == "" {
+		page.Title = info.Link
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := views.RenderSocial(w, page); err != nil {
+		log.Error().Err(err).Msg("Failed to render social meta page")
+	}
+}
+
+func (h *handler) renderPreview(w http.ResponseWriter, shortLink string, info models.DynamicLinkInfo) {
+	page := views.PreviewPage{
+		ShortLink: shortLink,
+		Info:      info,
+	}
+	if apn := info.AndroidParameters.AndroidPackageName; apn != "" {
+		page.PlayStoreLink = utils.PlayStoreURL(apn)
+	}
+	if isi := info.IosParameters.IosAppStoreId; isi != "" {
+		page.AppStoreLink = utils.AppStoreURL(isi)
+	}
+	page.Destinations = destinations(info)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := views.RenderPreview(w, page); err != nil {
+		log.Error().Err(err).Msg("Failed to render preview page")
+	}
+}
+
+func (h *handler) renderAppPreview(w http.ResponseWriter, target, clickID string, info models.DynamicLinkInfo) {
+	social := info.SocialMetaTagInfo
+	page := views.AppPreviewPage{
+		Title:       social.SocialTitle,
+		Description: social.SocialDescription,
+		ImageLink:   social.SocialImageLink,
+		Target:      target,
+		Link:        info.Link,
+		ClickID:     clickID,
+	}
+	if page.Title == "" {
+		page.Title = info.Link
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := views.RenderAppPreview(w, page); err != nil {
+		log.Error().Err(err).Msg("Failed to render app preview page")
+	}
+}
+
+func (h *handler) renderDebug(w http.ResponseWriter, shortLink string, info models.DynamicLinkInfo) {
+	page := views.DebugPage{
+		ShortLink:            shortLink,
+		Info:                 info,
+		EnableForcedRedirect: info.NavigationInfo.EnableForcedRedirect,
+		Destinations:         destinations(info),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := views.RenderDebug(w, page); err != nil {
+		log.Error().Err(err).Msg("Failed to render debug page")
+	}
+}
+
+func destinations(info models.DynamicLinkInfo) []views.Destination {
+	var result []views.Destination
+	for _, platform := range utils.Platforms {
+		decision := service.DecideRedirect(info, platform)
+		result = append(result, views.Destination{
+			Platform:       string(platform),
+			Target:         decision.Target,
+			Reason:         decision.Reason,
+			AppPreviewPage: service.ShowsAppPreview(info, platform),
+		})
+	}
+	return result
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"dynamic-links-generator/api/models"
+)
+
+type AppRepository interface {
+	GetIosApps(ctx context.Context, host string) ([]models.IosApp, error)
+	GetAndroidApps(ctx context.Context, host string) ([]models.AndroidApp, error)
+}
+
+type appRepository struct {
+	db *sql.DB
+}
+
+func NewAppRepository(db *sql.DB) AppRepository {
+	return &appRepository{
+		db: db,
+	}
+}
+
+func (r *appRepository) GetIosApps(ctx context.Context, host string) ([]models.IosApp, error) {
+	const q = `
+    SELECT team_id, bundle_id
+      FROM host_ios_apps
+     WHERE host = $1
+     ORDER BY bundle_id`
+
+	rows, err := r.db.QueryContext(ctx, q, host)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	var apps []models.IosApp
+	for rows.Next() {
+		var app models.IosApp
+		if err := rows.Scan(&app.TeamID, &app.BundleID); err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return apps, nil
+}
+
+func (r *appRepository) GetAndroidApps(ctx context.Context, host string) ([]models.AndroidApp, error) {
+	const q = `
+    SELECT package_name, sha256_cert_fingerprint
+      FROM host_android_apps
+     WHERE host = $1
+     ORDER BY package_name, sha256_cert_fingerprint`
+
+	rows, err := r.db.QueryContext(ctx, q, host)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	var apps []models.AndroidApp
+	for rows.Next() {
+		var packageName, fingerprint string
+		if err := rows.Scan(&packageName, &fingerprint); err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+
+		if n := len(apps); n > 0 && apps[n-1].PackageName == packageName {
+			apps[n-1].SHA256CertFingerprints = append(apps[n-1].SHA256CertFingerprints, fingerprint)
+			continue
+		}
+		apps = append(apps, models.AndroidApp{
+			PackageName:            packageName,
+			SHA256CertFingerprints: []string{fingerprint},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return apps, nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"dynamic-links-generator/api/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIosApps(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewAppRepository(db)
+
+	mock.ExpectQuery(`SELECT team_id, bundle_id FROM host_ios_apps`).
+		WithArgs("example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"team_id", "bundle_id"}).
+			AddRow("ABCDE12345", "com.example.app"))
+
+	apps, err := repo.GetIosApps(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.IosApp{{TeamID: "ABCDE12345", BundleID: "com.example.app"}}, apps)
+}
+
+func TestGetAndroidApps_GroupsFingerprints(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewAppRepository(db)
+
+	mock.ExpectQuery(`SELECT package_name, sha256_cert_fingerprint FROM host_android_apps`).
+		WithArgs("example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"package_name", "sha256_cert_fingerprint"}).
+			AddRow("com.example.app", "AA:BB").
+			AddRow("com.example.app", "CC:DD").
+			AddRow("com.example.other", "EE:FF"))
+
+	apps, err := repo.GetAndroidApps(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.AndroidApp{
+		{PackageName: "com.example.app", SHA256CertFingerprints: []string{"AA:BB", "CC:DD"}},
+		{PackageName: "com.example.other", SHA256CertFingerprints: []string{"EE:FF"}},
+	}, apps)
+}
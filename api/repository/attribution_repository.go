@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+)
+
+// Enough candidates to find the right device behind a shared address without
+// scanning a whole office or carrier NAT.
+const maxFingerprintCandidates = 50
+
+type AttributionRepository interface {
+	CreateFingerprint(ctx context.Context, fp models.ClickFingerprint) error
+	UpdateFingerprintBrowserSignals(ctx context.Context, id string, update models.ClickFingerprintUpdate) error
+	FindUnmatchedFingerprints(ctx context.Context, ipPrefix, appID string, since time.Time) ([]models.ClickFingerprint, error)
+	ClaimFingerprint(ctx context.Context, id string) (bool, error)
+	GetFingerprint(ctx context.Context, id string) (*models.ClickFingerprint, error)
+}
+
+const fingerprintColumns = `id, requested_link, host, path, app_id, platform, ip_prefix, device_model, os_version,
+           language, timezone, screen_width, screen_height, clicked_at`
+
+type attributionRepository struct {
+	db *sql.DB
+}
+
+func NewAttributionRepository(db *sql.DB) AttributionRepository {
+	return &attributionRepository{
+		db: db,
+	}
+}
+
+func (r *attributionRepository) CreateFingerprint(ctx context.Context, fp models.ClickFingerprint) error {
+	const stmt = `
+    INSERT INTO click_fingerprints
+      (id, requested_link, host, path, app_id, platform, ip_prefix, device_model, os_version, language, clicked_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err := r.db.ExecContext(ctx, stmt,
+		fp.ID,
+		fp.RequestedLink,
+		fp.Host,
+		fp.Path,
+		fp.AppID,
+		fp.Platform,
+		fp.IPPrefix,
+		fp.DeviceModel,
+		fp.OsVersion,
+		fp.Language,
+		fp.ClickedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert click fingerprint: %w", err)
+	}
+	return nil
+}
+
+// UpdateFingerprintBrowserSignals fills in what the app preview page read in
+// the browser. Only the first report is kept, and only before a match.
+func (r *attributionRepository) UpdateFingerprintBrowserSignals(ctx context.Context, id string, update models.ClickFingerprintUpdate) error {
+	const stmt = `
+    UPDATE click_fingerprints
+    SET timezone = $2, screen_width = $3, screen_height = $4
+    WHERE id = $1 AND timezone = '' AND screen_width = 0 AND matched_at IS NULL`
+
+	_, err := r.db.ExecContext(ctx, stmt, id, update.Timezone, update.ScreenWidth, update.ScreenHeight)
+	if err != nil {
+		return fmt.Errorf("failed to update click fingerprint: %w", err)
+	}
+	return nil
+}
+
+func (r *attributionRepository) FindUnmatchedFingerprints(ctx context.Context, ipPrefix, appID string, since time.Time) ([]models.ClickFingerprint, error) {
+	query := fmt.Sprintf(`
+    SELECT %s
+    FROM click_fingerprints
+    WHERE ip_prefix = $1 AND app_id = $2 AND clicked_at >= $3 AND matched_at IS NULL
+    ORDER BY clicked_at DESC
+    LIMIT %d`, fingerprintColumns, maxFingerprintCandidates)
+
+	rows, err := r.db.QueryContext(ctx, query, ipPrefix, appID, since)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	var fingerprints []models.ClickFingerprint
+	for rows.Next() {
+		fp, err := scanFingerprint(rows)
+		if err != nil {
+			return nil, err
+		}
+		fingerprints = append(fingerprints, *fp)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return fingerprints, nil
+}
+
+// ClaimFingerprint marks a fingerprint as matched. It reports false when
+// another install claimed it first, so one click is attributed only once.
+func (r *attributionRepository) ClaimFingerprint(ctx context.Context, id string) (bool, error) {
+	const stmt = `
+    UPDATE click_fingerprints
+    SET matched_at = NOW()
+    WHERE id = $1 AND matched_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, stmt, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim click fingerprint: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	return n == 1, nil
+}
+
+// GetFingerprint looks up a click by the attribution id handed to the app,
+// matched or not.
+func (r *attributionRepository) GetFingerprint(ctx context.Context, id string) (*models.ClickFingerprint, error) {
+	query := fmt.Sprintf(`SELECT %s FROM click_fingerprints WHERE id = $1`, fingerprintColumns)
+
+	fp, err := scanFingerprint(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperrors.ErrAttributionNotFound
+	}
+	return fp, err
+}
+
+type fingerprintScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanFingerprint(row fingerprintScanner) (*models.ClickFingerprint, error) {
+	var fp models.ClickFingerprint
+	err := row.Scan(
+		&fp.ID,
+		&fp.RequestedLink,
+		&fp.Host,
+		&fp.Path,
+		&fp.AppID,
+		&fp.Platform,
+		&fp.IPPrefix,
+		&fp.DeviceModel,
+		&fp.OsVersion,
+		&fp.Language,
+		&fp.Timezone,
+		&fp.ScreenWidth,
+		&fp.ScreenHeight,
+		&fp.ClickedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &fp, nil
+}
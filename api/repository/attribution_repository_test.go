@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dynamic-links-generator/api/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindUnmatchedFingerprints(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewAttributionRepository(db)
+
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	clicked := since.Add(time.Hour)
+	mock.ExpectQuery(`SELECT .* FROM click_fingerprints WHERE ip_prefix = \$1 AND app_id = \$2 AND clicked_at >= \$3 AND matched_at IS NULL ORDER BY clicked_at DESC LIMIT 50`).
+		WithArgs("203.0.113.0", "com.example.ios", since).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "requested_link", "host", "path", "app_id", "platform", "ip_prefix", "device_model",
+			"os_version", "language", "timezone", "screen_width", "screen_height", "clicked_at",
+		}).AddRow("c1", "https://example.page.link/abc", "example.page.link", "abc", "com.example.ios", "IOS",
+			"203.0.113.0", "iphone", "17.4", "en", "Europe/Berlin", 390, 844, clicked))
+
+	fps, err := repo.FindUnmatchedFingerprints(context.Background(), "203.0.113.0", "com.example.ios", since)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ClickFingerprint{{
+		ID: "c1", RequestedLink: "https://example.page.link/abc", Host: "example.page.link", Path: "abc",
+		AppID: "com.example.ios", Platform: "IOS", IPPrefix: "203.0.113.0", DeviceModel: "iphone",
+		OsVersion: "17.4", Language: "en", Timezone: "Europe/Berlin", ScreenWidth: 390, ScreenHeight: 844,
+		ClickedAt: clicked,
+	}}, fps)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimFingerprint(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewAttributionRepository(db)
+
+	mock.ExpectExec(`UPDATE click_fingerprints SET matched_at = NOW\(\) WHERE id = \$1 AND matched_at IS NULL`).
+		WithArgs("c1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE click_fingerprints SET matched_at`).
+		WithArgs("c1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	claimed, err := repo.ClaimFingerprint(context.Background(), "c1")
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = repo.ClaimFingerprint(context.Background(), "c1")
+	assert.NoError(t, err)
+	assert.False(t, claimed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"dynamic-links-generator/api/models"
+)
+
+type ConversionRepository interface {
+	InsertConversion(ctx context.Context, event models.ConversionEvent) error
+	LinkConversionTotals(ctx context.Context, host, path string, since time.Time) ([]models.ConversionTotal, error)
+	CampaignConversionTotals(ctx context.Context, campaign string, since time.Time) ([]models.ConversionTotal, error)
+}
+
+type conversionRepository struct {
+	db *sql.DB
+}
+
+func NewConversionRepository(db *sql.DB) ConversionRepository {
+	return &conversionRepository{
+		db: db,
+	}
+}
+
+func (r *conversionRepository) InsertConversion(ctx context.Context, e models.ConversionEvent) error {
+	const stmt = `
+    INSERT INTO conversion_events
+      (host, path, attribution_id, event_name, value, currency, platform,
+       utm_source, utm_medium, utm_campaign, utm_term, utm_content, occurred_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	var value sql.NullFloat64
+	if e.Value != nil {
+		value = sql.NullFloat64{Float64: *e.Value, Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, stmt,
+		e.Host,
+		e.Path,
+		e.AttributionID,
+		e.EventName,
+		value,
+		e.Currency,
+		e.Platform,
+		e.Utm.UtmSource,
+		e.Utm.UtmMedium,
+		e.Utm.UtmCampaign,
+		e.Utm.UtmTerm,
+		e.Utm.UtmContent,
+		e.OccurredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert conversion event: %w", err)
+	}
+	return nil
+}
+
+// LinkConversionTotals counts and sums the conversions credited to (host,
+// path) since the given time, per event name and currency.
+func (r *conversionRepository) LinkConversionTotals(ctx context.Context, host, path string, since time.Time) ([]models.ConversionTotal, error) {
+	const query = `
+    SELECT event_name, currency, COUNT(*), COALESCE(SUM(value), 0)
+    FROM conversion_events
+    WHERE host = $1 AND path = $2 AND occurred_at >= $3
+    GROUP BY event_name, currency`
+
+	return r.queryConversionTotals(ctx, query, host, path, since)
+}
+
+func (r *conversionRepository) CampaignConversionTotals(ctx context.Context, campaign string, since time.Time) ([]models.ConversionTotal, error) {
+	const query = `
+    SELECT event_name, currency, COUNT(*), COALESCE(SUM(value), 0)
+    FROM conversion_events
+    WHERE utm_campaign = $1 AND occurred_at >= $2
+    GROUP BY event_name, currency`
+
+	return r.queryConversionTotals(ctx, query, campaign, since)
+}
+
+func (r *conversionRepository) queryConversionTotals(ctx context.Context, query string, args ...any) ([]models.ConversionTotal, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []models.ConversionTotal
+	for rows.Next() {
+		var t models.ConversionTotal
+		if err := rows.Scan(&t.EventName, &t.Currency, &t.Count, &t.Value); err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		totals = append(totals, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return totals, nil
+}
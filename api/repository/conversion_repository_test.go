@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"dynamic-links-generator/api/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertConversion(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewConversionRepository(db)
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	value := 9.99
+	mock.ExpectExec(`INSERT INTO conversion_events`).
+		WithArgs("example.page.link", "sale", "c1", "purchase", sql.NullFloat64{Float64: 9.99, Valid: true}, "EUR", "IOS",
+			"newsletter", "email", "spring", "", "", at).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO conversion_events`).
+		WithArgs("example.page.link", "sale", "", "APP_FIRST_OPEN", sql.NullFloat64{}, "", "ANDROID",
+			"", "", "", "", "", at).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	assert.NoError(t, repo.InsertConversion(context.Background(), models.ConversionEvent{
+		Host: "example.page.link", Path: "sale", AttributionID: "c1", EventName: "purchase",
+		Value: &value, Currency: "EUR", Platform: "IOS",
+		Utm:        models.MarketingParameters{UtmSource: "newsletter", UtmMedium: "email", UtmCampaign: "spring"},
+		OccurredAt: at,
+	}))
+	assert.NoError(t, repo.InsertConversion(context.Background(), models.ConversionEvent{
+		Host: "example.page.link", Path: "sale", EventName: "APP_FIRST_OPEN", Platform: "ANDROID", OccurredAt: at,
+	}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCampaignConversionTotals(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewConversionRepository(db)
+
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT event_name, currency, COUNT\(\*\), COALESCE\(SUM\(value\), 0\) FROM conversion_events WHERE utm_campaign = \$1`).
+		WithArgs("spring", since).
+		WillReturnRows(sqlmock.NewRows([]string{"event_name", "currency", "count", "sum"}).
+			AddRow("APP_FIRST_OPEN", "", 40, 0).
+			AddRow("purchase", "EUR", 3, 29.97))
+
+	totals, err := repo.CampaignConversionTotals(context.Background(), "spring", since)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ConversionTotal{
+		{EventName: "APP_FIRST_OPEN", Count: 40},
+		{EventName: "purchase", Currency: "EUR", Count: 3, Value: 29.97},
+	}, totals)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+)
+
+type DomainRepository interface {
+	ListDomains(ctx context.Context) ([]models.Domain, error)
+	GetDomain(ctx context.Context, host string) (*models.Domain, error)
+	CreateDomain(ctx context.Context, host string, settings models.DomainSettings) (*models.Domain, error)
+	UpdateDomainSettings(ctx context.Context, host string, settings models.DomainSettings) (*models.Domain, error)
+	DeleteDomain(ctx context.Context, host string) error
+}
+
+type domainRepository struct {
+	db *sql.DB
+}
+
+func NewDomainRepository(db *sql.DB) DomainRepository {
+	return &domainRepository{
+		db: db,
+	}
+}
+
+func (r *domainRepository) ListDomains(ctx context.Context) ([]models.Domain, error) {
+	const q = `
+    SELECT host, settings, created_at
+      FROM domains
+     ORDER BY host`
+
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	domains := []models.Domain{}
+	for rows.Next() {
+		domain, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		domains = append(domains, *domain)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return domains, nil
+}
+
+func (r *domainRepository) GetDomain(ctx context.Context, host string) (*models.Domain, error) {
+	const q = `
+    SELECT host, settings, created_at
+      FROM domains
+     WHERE host = $1`
+
+	domain, err := scanDomain(r.db.QueryRowContext(ctx, q, host))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrDomainNotRegistered, host)
+	}
+	return domain, err
+}
+
+func (r *domainRepository) CreateDomain(ctx context.Context, host string, settings models.DomainSettings) (*models.Domain, error) {
+	const stmt = `
+    INSERT INTO domains (host, settings)
+    VALUES ($1, $2)
+    RETURNING host, settings, created_at`
+
+	rawSettings, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode domain settings: %w", err)
+	}
+
+	domain, err := scanDomain(r.db.QueryRowContext(ctx, stmt, host, rawSettings))
+	if isUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrDomainAlreadyExists, host)
+	}
+	return domain, err
+}
+
+func (r *domainRepository) UpdateDomainSettings(ctx context.Context, host string, settings models.DomainSettings) (*models.Domain, error) {
+	const stmt = `
+    UPDATE domains
+       SET settings = $2
+     WHERE host = $1
+    RETURNING host, settings, created_at`
+
+	rawSettings, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode domain settings: %w", err)
+	}
+
+	domain, err := scanDomain(r.db.QueryRowContext(ctx, stmt, host, rawSettings))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrDomainNotRegistered, host)
+	}
+	return domain, err
+}
+
+func (r *domainRepository) DeleteDomain(ctx context.Context, host string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM domains WHERE host = $1`, host)
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("database error: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s", apperrors.ErrDomainNotRegistered, host)
+	}
+	return nil
+}
+
+type domainScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDomain(row domainScanner) (*models.Domain, error) {
+	var domain models.Domain
+	var rawSettings []byte
+	if err := row.Scan(&domain.Host, &rawSettings, &domain.CreatedAt); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if err := json.Unmarshal(rawSettings, &domain.Settings); err != nil {
+		return nil, fmt.Errorf("invalid settings for domain %s: %w", domain.Host, err)
+	}
+	return &domain, nil
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDomain(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewDomainRepository(db)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery(`SELECT host, settings, created_at FROM domains WHERE host = \$1`).
+		WithArgs("example.page.link").
+		WillReturnRows(sqlmock.NewRows([]string{"host", "settings", "created_at"}).
+			AddRow("example.page.link", []byte(`{"shortPathLength":8,"defaultAppParameters":{"androidPackageName":"com.example.app"}}`), created))
+
+	domain, err := repo.GetDomain(context.Background(), "example.page.link")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Domain{
+		Host: "example.page.link",
+		Settings: models.DomainSettings{
+			ShortPathLength:      8,
+			DefaultAppParameters: models.DefaultAppParameters{AndroidPackageName: "com.example.app"},
+		},
+		CreatedAt: created,
+	}, domain)
+}
+
+func TestGetDomain_NotRegistered(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewDomainRepository(db)
+
+	mock.ExpectQuery(`SELECT host, settings, created_at FROM domains`).
+		WithArgs("typo.page.link").
+		WillReturnRows(sqlmock.NewRows([]string{"host", "settings", "created_at"}))
+
+	_, err := repo.GetDomain(context.Background(), "typo.page.link")
+	assert.ErrorIs(t, err, apperrors.ErrDomainNotRegistered)
+}
+
+func TestCreateDomain_AlreadyExists(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewDomainRepository(db)
+
+	mock.ExpectQuery(`INSERT INTO domains`).
+		WithArgs("example.page.link", []byte(`{"defaultAppParameters":{}}`)).
+		WillReturnError(sqlStateError("23505"))
+
+	_, err := repo.CreateDomain(context.Background(), "example.page.link", models.DomainSettings{})
+	assert.ErrorIs(t, err, apperrors.ErrDomainAlreadyExists)
+}
+
+func TestUpdateDomainSettings_NotRegistered(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewDomainRepository(db)
+
+	mock.ExpectQuery(`UPDATE domains SET settings = \$2 WHERE host = \$1`).
+		WithArgs("typo.page.link", []byte(`{"urlScheme":"http","defaultAppParameters":{}}`)).
+		WillReturnRows(sqlmock.NewRows([]string{"host", "settings", "created_at"}))
+
+	_, err := repo.UpdateDomainSettings(context.Background(), "typo.page.link", models.DomainSettings{URLScheme: "http"})
+	assert.ErrorIs(t, err, apperrors.ErrDomainNotRegistered)
+}
+
+func TestDeleteDomain_NotRegistered(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewDomainRepository(db)
+
+	mock.ExpectExec(`DELETE FROM domains WHERE host = \$1`).
+		WithArgs("typo.page.link").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.DeleteDomain(context.Background(), "typo.page.link")
+	assert.ErrorIs(t, err, apperrors.ErrDomainNotRegistered)
+}
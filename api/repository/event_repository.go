@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"dynamic-links-generator/api/models"
+)
+
+const linkEventColumns = 12
+
+// Postgres accepts at most 65535 bind parameters per statement.
+const maxEventsPerInsert = 65535 / linkEventColumns
+
+type EventRepository interface {
+	InsertEvents(ctx context.Context, events []models.LinkEvent) error
+	CountEvents(ctx context.Context, host, path string, since time.Time) ([]models.EventCount, error)
+	CountCampaignEvents(ctx context.Context, campaign string, since time.Time) ([]models.EventCount, error)
+}
+
+type eventRepository struct {
+	db *sql.DB
+}
+
+func NewEventRepository(db *sql.DB) EventRepository {
+	return &eventRepository{
+		db: db,
+	}
+}
+
+func (r *eventRepository) InsertEvents(ctx context.Context, events []models.LinkEvent) error {
+	for len(events) > 0 {
+		n := min(len(events), maxEventsPerInsert)
+		if err := r.insertEvents(ctx, events[:n]); err != nil {
+			return err
+		}
+		events = events[n:]
+	}
+	return nil
+}
+
+func (r *eventRepository) insertEvents(ctx context.Context, events []models.LinkEvent) error {
+	var stmt strings.Builder
+	stmt.WriteString(`
+    INSERT INTO link_events
+      (host, path, event_type, source, platform, referrer, ip_prefix, user_agent_class,
+       utm_source, utm_medium, utm_campaign, occurred_at)
+    VALUES `)
+
+	args := make([]any, 0, len(events)*linkEventColumns)
+	for i, e := range events {
+		if i > 0 {
+			stmt.WriteString(", ")
+		}
+		stmt.WriteString("(")
+		for col := range linkEventColumns {
+			if col > 0 {
+				stmt.WriteString(", ")
+			}
+			fmt.Fprintf(&stmt, "$%d", len(args)+col+1)
+		}
+		stmt.WriteString(")")
+
+		args = append(args,
+			e.Host,
+			e.Path,
+			e.EventType,
+			e.Source,
+			e.Platform,
+			e.Referrer,
+			e.IPPrefix,
+			e.UserAgentClass,
+			e.UtmSource,
+			e.UtmMedium,
+			e.UtmCampaign,
+			e.OccurredAt,
+		)
+	}
+
+	if _, err := r.db.ExecContext(ctx, stmt.String(), args...); err != nil {
+		return fmt.Errorf("failed to insert link events: %w", err)
+	}
+	return nil
+}
+
+// CountEvents counts the events on (host, path) since the given time, grouped
+// by platform and event type. It reads the rollups: daily buckets for whole
+// days and hourly buckets for the part before the first one, so the start is
+// accurate to the hour. Crawler fetches are not rolled up.
+func (r *eventRepository) CountEvents(ctx context.Context, host, path string, since time.Time) ([]models.EventCount, error) {
+	const query = `
+    SELECT platform, event_type, SUM(count)
+    FROM (
+      SELECT platform, event_type, count FROM link_event_rollups_daily
+      WHERE host = $1 AND path = $2 AND bucket >= $4
+      UNION ALL
+      SELECT platform, event_type, count FROM link_event_rollups_hourly
+      WHERE host = $1 AND path = $2 AND bucket >= $3 AND bucket < $4
+    ) rollups
+    GROUP BY platform, event_type`
+
+	fromHour, fromDay := rollupRange(since)
+	return r.queryEventCounts(ctx, query, host, path, fromHour, fromDay)
+}
+
+// CountCampaignEvents is CountEvents over every link tagged with utm_campaign.
+func (r *eventRepository) CountCampaignEvents(ctx context.Context, campaign string, since time.Time) ([]models.EventCount, error) {
+	const query = `
+    SELECT platform, event_type, SUM(count)
+    FROM (
+      SELECT platform, event_type, count FROM link_event_rollups_daily
+      WHERE utm_campaign = $1 AND bucket >= $3
+      UNION ALL
+      SELECT platform, event_type, count FROM link_event_rollups_hourly
+      WHERE utm_campaign = $1 AND bucket >= $2 AND bucket < $3
+    ) rollups
+    GROUP BY platform, event_type`
+
+	fromHour, fromDay := rollupRange(since)
+	return r.queryEventCounts(ctx, query, campaign, fromHour, fromDay)
+}
+
+// rollupRange splits the time since `since` into hourly buckets up to the
+// next UTC midnight and daily buckets from there on.
+func rollupRange(since time.Time) (fromHour, fromDay time.Time) {
+	fromHour = since.UTC().Truncate(time.Hour)
+	fromDay = fromHour.Truncate(24 * time.Hour)
+	if fromDay.Before(fromHour) {
+		fromDay = fromDay.Add(24 * time.Hour)
+	}
+	return fromHour, fromDay
+}
+
+func (r *eventRepository) queryEventCounts(ctx context.Context, query string, args ...any) ([]models.EventCount, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []models.EventCount
+	for rows.Next() {
+		var c models.EventCount
+		if err := rows.Scan(&c.Platform, &c.EventType, &c.Count); err != nil {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"dynamic-links-generator/api/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertEvents(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewEventRepository(db)
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	events := []models.LinkEvent{
+		{Host: "example.page.link", Path: "abc", EventType: models.EventTypeClick, Source: models.EventSourceRedirect, Platform: "IOS", IPPrefix: "203.0.113.0", UserAgentClass: "BROWSER", UtmSource: "newsletter", UtmMedium: "email", UtmCampaign: "spring", OccurredAt: at},
+		{Host: "example.page.link", Path: "xyz", EventType: models.EventTypeClick, Source: models.EventSourceExchange, Platform: "ANDROID", Referrer: "https://ref.example", OccurredAt: at},
+	}
+
+	mock.ExpectExec(`INSERT INTO link_events .* VALUES \(\$1, .*, \$12\), \(\$13, .*, \$24\)`).
+		WithArgs(
+			"example.page.link", "abc", "CLICK", "REDIRECT", "IOS", "", "203.0.113.0", "BROWSER", "newsletter", "email", "spring", at,
+			"example.page.link", "xyz", "CLICK", "EXCHANGE", "ANDROID", "https://ref.example", "", "", "", "", "", at,
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, repo.InsertEvents(context.Background(), events))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertEvents_Error(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewEventRepository(db)
+
+	mock.ExpectExec(`INSERT INTO link_events`).WillReturnError(errors.New("connection reset"))
+
+	err := repo.InsertEvents(context.Background(), []models.LinkEvent{{Host: "example.page.link", Path: "abc"}})
+	assert.Error(t, err)
+}
+
+func TestCountEvents(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewEventRepository(db)
+
+	since := time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT platform, event_type, SUM\(count\) FROM \( SELECT .* FROM link_event_rollups_daily .* UNION ALL SELECT .* FROM link_event_rollups_hourly .*\) rollups GROUP BY platform, event_type`).
+		WithArgs("example.page.link", "abc", time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"platform", "event_type", "sum"}).
+			AddRow("ANDROID", "CLICK", 12).
+			AddRow("IOS", "REDIRECT", 3))
+
+	counts, err := repo.CountEvents(context.Background(), "example.page.link", "abc", since)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.EventCount{
+		{Platform: "ANDROID", EventType: "CLICK", Count: 12},
+		{Platform: "IOS", EventType: "REDIRECT", Count: 3},
+	}, counts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRollupRange(t *testing.T) {
+	tests := []struct {
+		since    time.Time
+		fromHour time.Time
+		fromDay  time.Time
+	}{
+		{
+			time.Date(2024, 1, 1, 15, 30, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			time.Date(2024, 1, 1, 23, 10, 0, 0, time.FixedZone("UTC-2", -2*60*60)),
+			time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.since.String(), func(t *testing.T) {
+			fromHour, fromDay := rollupRange(tt.since)
+			assert.Equal(t, tt.fromHour, fromHour)
+			assert.Equal(t, tt.fromDay, fromDay)
+		})
+	}
+}
@@ -15,6 +15,8 @@ type LinkRepository interface {
 	GetQueryParamsByHostAndPath(ctx context.Context, host, path string) (string, error)
 	FindExistingShortLink(ctx context.Context, host, rawQS string) (string, error)
 	CreateShortLink(ctx context.Context, host, path, rawQS string, unguessable bool) error
+	CreateOrGetShortLink(ctx context.Context, host, path, rawQS string) (string, error)
+	CreateCustomLink(ctx context.Context, host, path, rawQS string) error
 }
 
 type linkRepository struct {
@@ -61,8 +63,10 @@ func (r *linkRepository) FindExistingShortLink(ctx context.Context, host, rawQS
     SELECT path
       FROM dynamic_links
      WHERE host                = $1
+       AND md5(query_params)   = md5($2)
        AND query_params        = $2
        AND is_unguessable_path = FALSE
+       AND is_custom_path      = FALSE
      LIMIT 1`
 	err := r.db.QueryRowContext(ctx, q, host, rawQS).Scan(&path)
 	return path, err
@@ -81,5 +85,63 @@ func (r *linkRepository) CreateShortLink(ctx context.Context, host, path, rawQS
 		rawQS,
 		unguessable,
 	)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s/%s", apperrors.ErrPathConflict, host, path)
+	}
+	return err
+}
+
+func (r *linkRepository) CreateCustomLink(ctx context.Context, host, path, rawQS string) error {
+	const stmt = `
+    INSERT INTO dynamic_links
+      (host, path, query_params, is_unguessable_path, is_custom_path)
+    VALUES ($1, $2, $3, FALSE, TRUE)`
+
+	_, err := r.db.ExecContext(ctx, stmt, host, path, rawQS)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("%w: %s/%s", apperrors.ErrPathConflict, host, path)
+	}
 	return err
 }
+
+// isUniqueViolation reports whether err is a Postgres unique_violation
+// (SQLSTATE 23505). Both lib/pq and pgx errors expose SQLState.
+func isUniqueViolation(err error) bool {
+	var sqlErr interface{ SQLState() string }
+	return errors.As(err, &sqlErr) && sqlErr.SQLState() == "23505"
+}
+
+// CreateOrGetShortLink inserts a SHORT link unless one already exists for the
+// same (host, query_params), in which case the existing path is returned. The
+// unique index on the query hash makes this atomic across concurrent creates.
+func (r *linkRepository) CreateOrGetShortLink(ctx context.Context, host, path, rawQS string) (string, error) {
+	const stmt = `
+    INSERT INTO dynamic_links
+      (host, path, query_params, is_unguessable_path)
+    VALUES ($1, $2, $3, FALSE)
+    ON CONFLICT (host, md5(query_params))
+       WHERE is_unguessable_path = FALSE AND is_custom_path = FALSE
+    DO NOTHING
+    RETURNING path`
+
+	var stored string
+	err := r.db.QueryRowContext(ctx, stmt, host, path, rawQS).Scan(&stored)
+	switch {
+	case err == nil:
+		return stored, nil
+	case isUniqueViolation(err):
+		return "", fmt.Errorf("%w: %s/%s", apperrors.ErrPathConflict, host, path)
+	case !errors.Is(err, sql.ErrNoRows):
+		return "", err
+	}
+
+	existing, err := r.FindExistingShortLink(ctx, host, rawQS)
+	if err != nil {
+		return "", fmt.Errorf("failed to load existing short link: %w", err)
+	}
+	log.Debug().
+		Str("host", host).
+		Str("path", existing).
+		Msg("Short link already exists for query params")
+	return existing, nil
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/db"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/rs/zerolog"
@@ -22,6 +28,55 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+// setupPostgresDB migrates a throwaway schema on TEST_DATABASE_URL and
+// returns a pool whose connections all use it. Tests using it are skipped
+// without one.
+func setupPostgresDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %s", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := fmt.Sprintf("repository_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatalf("failed to create schema: %s", err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	// lib/pq passes unknown settings on as run-time parameters.
+	if u, err := url.Parse(dsn); err == nil && strings.Contains(dsn, "://") {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		dsn = u.String()
+	} else {
+		dsn += " search_path=" + schema
+	}
+	pool, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %s", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+
+	migrations, err := db.LoadMigrations()
+	if err != nil {
+		t.Fatalf("failed to load migrations: %s", err)
+	}
+	for _, m := range migrations {
+		if _, err := pool.Exec(m.Up); err != nil {
+			t.Fatalf("migration %d_%s failed: %s", m.Version, m.Name, err)
+		}
+	}
+	return pool
+}
+
 func setupMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock, LinkRepository) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -127,3 +182,133 @@ func TestGetQueryParamsByHostAndPath_DBError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "connection lost")
 }
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sql error " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestCreateShortLink_UniqueViolation(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`INSERT INTO dynamic_links`).
+		WithArgs("example.com", "abc123", "apn=com.app&amv=1", false).
+		WillReturnError(sqlStateError("23505"))
+
+	err := repo.CreateShortLink(context.Background(), "example.com", "abc123", "apn=com.app&amv=1", false)
+	assert.ErrorIs(t, err, apperrors.ErrPathConflict)
+}
+
+func TestCreateShortLink_OtherConstraintError(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`INSERT INTO dynamic_links`).
+		WithArgs("example.com", "abc123", "apn=com.app&amv=1", false).
+		WillReturnError(sqlStateError("23502"))
+
+	err := repo.CreateShortLink(context.Background(), "example.com", "abc123", "apn=com.app&amv=1", false)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, apperrors.ErrPathConflict))
+}
+
+func TestCreateOrGetShortLink_Inserted(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`INSERT INTO dynamic_links .* ON CONFLICT .* DO NOTHING RETURNING path`).
+		WithArgs("example.com", "abc123", "apn=com.app&amv=1").
+		WillReturnRows(sqlmock.NewRows([]string{"path"}).AddRow("abc123"))
+
+	path, err := repo.CreateOrGetShortLink(context.Background(), "example.com", "abc123", "apn=com.app&amv=1")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", path)
+}
+
+func TestCreateOrGetShortLink_ReturnsExisting(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	// The conflict target must name the partial unique index on the query
+	// hash, predicate included, or Postgres rejects the statement.
+	mock.ExpectQuery(`INSERT INTO dynamic_links \(host, path, query_params, is_unguessable_path\) VALUES \(\$1, \$2, \$3, FALSE\) `+
+		`ON CONFLICT \(host, md5\(query_params\)\) WHERE is_unguessable_path = FALSE AND is_custom_path = FALSE DO NOTHING RETURNING path`).
+		WithArgs("example.com", "abc123", "apn=com.app&amv=1").
+		WillReturnRows(sqlmock.NewRows([]string{"path"}))
+	mock.ExpectQuery(`SELECT path FROM dynamic_links WHERE host = \$1 AND md5\(query_params\) = md5\(\$2\) AND query_params = \$2 `+
+		`AND is_unguessable_path = FALSE AND is_custom_path = FALSE LIMIT 1`).
+		WithArgs("example.com", "apn=com.app&amv=1").
+		WillReturnRows(sqlmock.NewRows([]string{"path"}).AddRow("xyz789"))
+
+	path, err := repo.CreateOrGetShortLink(context.Background(), "example.com", "abc123", "apn=com.app&amv=1")
+	assert.NoError(t, err)
+	assert.Equal(t, "xyz789", path)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateOrGetShortLink_ExistingGone(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	// The conflicting row can be deleted before it is re-read.
+	mock.ExpectQuery(`INSERT INTO dynamic_links`).
+		WithArgs("example.com", "abc123", "apn=com.app&amv=1").
+		WillReturnRows(sqlmock.NewRows([]string{"path"}))
+	mock.ExpectQuery(`SELECT path FROM dynamic_links`).
+		WithArgs("example.com", "apn=com.app&amv=1").
+		WillReturnRows(sqlmock.NewRows([]string{"path"}))
+
+	_, err := repo.CreateOrGetShortLink(context.Background(), "example.com", "abc123", "apn=com.app&amv=1")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateOrGetShortLink_ConcurrentPostgres(t *testing.T) {
+	repo := NewLinkRepository(setupPostgresDB(t))
+
+	const workers = 20
+	var wg sync.WaitGroup
+	paths := make([]string, workers)
+	errs := make([]error, workers)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			paths[i], errs[i] = repo.CreateOrGetShortLink(context.Background(), "example.com", fmt.Sprintf("path%02d", i), "link=https%3A%2F%2Ftarget.com")
+		}()
+	}
+	wg.Wait()
+
+	for i := range workers {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, paths[0], paths[i])
+	}
+	existing, err := repo.FindExistingShortLink(context.Background(), "example.com", "link=https%3A%2F%2Ftarget.com")
+	assert.NoError(t, err)
+	assert.Equal(t, paths[0], existing)
+}
+
+func TestCreateOrGetShortLink_PathConflict(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`INSERT INTO dynamic_links`).
+		WithArgs("example.com", "abc123", "apn=com.app&amv=1").
+		WillReturnError(sqlStateError("23505"))
+
+	_, err := repo.CreateOrGetShortLink(context.Background(), "example.com", "abc123", "apn=com.app&amv=1")
+	assert.ErrorIs(t, err, apperrors.ErrPathConflict)
+}
+
+func TestCreateCustomLink_AlreadyExists(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectExec(`INSERT INTO dynamic_links .* is_custom_path`).
+		WithArgs("example.com", "spring-sale", "link=https%3A%2F%2Ftarget.com").
+		WillReturnError(sqlStateError("23505"))
+
+	err := repo.CreateCustomLink(context.Background(), "example.com", "spring-sale", "link=https%3A%2F%2Ftarget.com")
+	assert.ErrorIs(t, err, apperrors.ErrPathConflict)
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Key for the Postgres advisory lock that keeps concurrent instances from
+// aggregating at the same time.
+const rollupLockKey = 0x6c696e6b5f726f6c // "link_rol"
+
+// Raw events are deleted in batches so a purge does not hold long locks.
+const purgeBatchSize = 10000
+
+type RollupRepository interface {
+	AggregateEvents(ctx context.Context, horizon time.Time, overlap time.Duration) (bool, error)
+	PurgeEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+type rollupRepository struct {
+	db *sql.DB
+}
+
+func NewRollupRepository(db *sql.DB) RollupRepository {
+	return &rollupRepository{
+		db: db,
+	}
+}
+
+// AggregateEvents recomputes, from raw events, every hourly and daily bucket
+// that received an event since the last run, so events recorded late land in
+// the right bucket. Buckets before horizon may already be partly purged and
+// are left alone. overlap re-reads events recorded shortly before the last
+// run, covering transactions that committed after it. It reports false when
+// another instance holds the lock.
+func (r *rollupRepository) AggregateEvents(ctx context.Context, horizon time.Time, overlap time.Duration) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, rollupLockKey).Scan(&locked); err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	if !locked {
+		return false, nil
+	}
+
+	var from, until time.Time
+	err = tx.QueryRowContext(ctx, `
+    SELECT COALESCE((SELECT watermark FROM rollup_state WHERE name = 'link_events'), 'epoch'::timestamptz), NOW()`,
+	).Scan(&from, &until)
+	if err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	from = from.Add(-overlap)
+
+	const hourly = `
+    WITH touched AS (
+      SELECT DISTINCT host, path, platform, event_type, date_trunc('hour', occurred_at, 'UTC') AS bucket
+      FROM link_events
+      WHERE recorded_at >= $1 AND occurred_at >= $2 AND user_agent_class <> 'CRAWLER'
+    )
+    INSERT INTO link_event_rollups_hourly (host, path, platform, event_type, bucket, utm_campaign, count)
+    SELECT t.host, t.path, t.platform, t.event_type, t.bucket, MAX(e.utm_campaign), COUNT(*)
+    FROM touched t
+    JOIN link_events e
+      ON e.host = t.host AND e.path = t.path AND e.platform = t.platform AND e.event_type = t.event_type
+     AND e.occurred_at >= t.bucket AND e.occurred_at < t.bucket + INTERVAL '1 hour'
+    WHERE e.user_agent_class <> 'CRAWLER'
+    GROUP BY t.host, t.path, t.platform, t.event_type, t.bucket
+    ON CONFLICT (host, path, platform, event_type, bucket)
+    DO UPDATE SET count = EXCLUDED.count, utm_campaign = EXCLUDED.utm_campaign`
+
+	const daily = `
+    WITH touched AS (
+      SELECT DISTINCT host, path, platform, event_type, date_trunc('day', occurred_at, 'UTC') AS bucket
+      FROM link_events
+      WHERE recorded_at >= $1 AND occurred_at >= $2 AND user_agent_class <> 'CRAWLER'
+    )
+    INSERT INTO link_event_rollups_daily (host, path, platform, event_type, bucket, utm_campaign, count)
+    SELECT t.host, t.path, t.platform, t.event_type, t.bucket, MAX(h.utm_campaign), SUM(h.count)
+    FROM touched t
+    JOIN link_event_rollups_hourly h
+      ON h.host = t.host AND h.path = t.path AND h.platform = t.platform AND h.event_type = t.event_type
+     AND h.bucket >= t.bucket AND h.bucket < t.bucket + INTERVAL '1 day'
+    GROUP BY t.host, t.path, t.platform, t.event_type, t.bucket
+    ON CONFLICT (host, path, platform, event_type, bucket)
+    DO UPDATE SET count = EXCLUDED.count, utm_campaign = EXCLUDED.utm_campaign`
+
+	// Daily buckets are summed from hourly ones, so hourly goes first.
+	for _, stmt := range []string{hourly, daily} {
+		if _, err := tx.ExecContext(ctx, stmt, from, horizon); err != nil {
+			return false, fmt.Errorf("failed to aggregate link events: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+    INSERT INTO rollup_state (name, watermark) VALUES ('link_events', $1)
+    ON CONFLICT (name) DO UPDATE SET watermark = EXCLUDED.watermark`, until)
+	if err != nil {
+		return false, fmt.Errorf("failed to update rollup watermark: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("database error: %w", err)
+	}
+	return true, nil
+}
+
+// PurgeEvents deletes raw events that happened before the given time and
+// returns how many were deleted. Rollups are kept.
+func (r *rollupRepository) PurgeEvents(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+    DELETE FROM link_events
+    WHERE id IN (SELECT id FROM link_events WHERE occurred_at < $1 LIMIT %d)`, purgeBatchSize)
+
+	var total int64
+	for {
+		res, err := r.db.ExecContext(ctx, query, before)
+		if err != nil {
+			return total, fmt.Errorf("failed to purge link events: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("database error: %w", err)
+		}
+		total += n
+		if n < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
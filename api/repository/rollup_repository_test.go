@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateEvents(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewRollupRepository(db)
+
+	watermark := time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+	until := watermark.Add(time.Minute)
+	horizon := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	from := watermark.Add(-5 * time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(\$1\)`).
+		WithArgs(rollupLockKey).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery(`SELECT COALESCE\(\(SELECT watermark FROM rollup_state`).
+		WillReturnRows(sqlmock.NewRows([]string{"watermark", "now"}).AddRow(watermark, until))
+	mock.ExpectExec(`INSERT INTO link_event_rollups_hourly .* FROM touched t JOIN link_events e`).
+		WithArgs(from, horizon).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(`INSERT INTO link_event_rollups_daily .* FROM touched t JOIN link_event_rollups_hourly h`).
+		WithArgs(from, horizon).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO rollup_state`).
+		WithArgs(until).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ran, err := repo.AggregateEvents(context.Background(), horizon, 5*time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAggregateEvents_Locked(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewRollupRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).
+		WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectRollback()
+
+	ran, err := repo.AggregateEvents(context.Background(), time.Time{}, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ran)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurgeEvents(t *testing.T) {
+	db, mock, _ := setupMockDB(t)
+	defer db.Close()
+	repo := NewRollupRepository(db)
+
+	before := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`DELETE FROM link_events WHERE id IN \(SELECT id FROM link_events WHERE occurred_at < \$1 LIMIT 10000\)`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, purgeBatchSize))
+	mock.ExpectExec(`DELETE FROM link_events`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	purged, err := repo.PurgeEvents(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(purgeBatchSize+42), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -12,7 +12,7 @@ import (
 	"dynamic-links-generator/config"
 )
 
-func NewRouter(database *sql.DB, cfg *config.Config) *chi.Mux {
+func NewRouter(database *sql.DB, cfg *config.Config, events service.EventRecorder) *chi.Mux {
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
@@ -26,13 +26,55 @@ func NewRouter(database *sql.DB, cfg *config.Config) *chi.Mux {
 	}))
 
 	linkRepository := repository.NewLinkRepository(database)
-	linkService := service.NewLinkService(linkRepository, cfg)
-	handler := NewHandler(linkService)
+	appRepository := repository.NewAppRepository(database)
+	domainRepository := repository.NewDomainRepository(database)
+	eventRepository := repository.NewEventRepository(database)
+	attributionRepository := repository.NewAttributionRepository(database)
+	conversionRepository := repository.NewConversionRepository(database)
+	linkService := service.NewLinkService(linkRepository, domainRepository, cfg)
+	appService := service.NewAppService(appRepository)
+	domainService := service.NewDomainService(domainRepository, cfg)
+	statsService := service.NewStatsService(linkService, eventRepository)
+	attributionService := service.NewAttributionService(attributionRepository, linkService, cfg)
+	conversionService := service.NewConversionService(conversionRepository, attributionRepository, eventRepository, events, linkService)
+	handler := NewHandler(linkService, appService, domainService, statsService, attributionService, conversionService, events)
 
 	r.Route("/v1", func(r chi.Router) {
 		r.Post("/shortLinks", handler.CreateLink)
 		r.Post("/exchangeShortLink", handler.ExchangeShortLink)
+		r.Post("/installAttribution", handler.InstallAttribution)
+		r.Post("/reopenAttribution", handler.ReopenAttribution)
+		r.Post("/clickFingerprints/{id}", handler.UpdateClickFingerprint)
+
+		// Click statistics are not public, as with Firebase's API key.
+		r.Group(func(r chi.Router) {
+			r.Use(RequireAPIKey("Stats", cfg.StatsAPIKey))
+			// The short link is passed URL-encoded, as in Firebase's API.
+			r.Get("/{shortDynamicLink}/linkStats", handler.LinkStats)
+		})
+
+		// Conversions come from app backends and carry revenue, so writing
+		// and reading them needs the conversion API key.
+		r.Group(func(r chi.Router) {
+			r.Use(RequireAPIKey("Conversion", cfg.ConversionAPIKey))
+			r.Post("/conversionEvents", handler.RecordConversion)
+			r.Get("/{shortDynamicLink}/conversionStats", handler.LinkConversionStats)
+			r.Get("/campaigns/{campaign}/conversionStats", handler.CampaignConversionStats)
+		})
+
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(RequireAdminKey(cfg.AdminAPIKey))
+			r.Get("/domains", handler.ListDomains)
+			r.Post("/domains", handler.RegisterDomain)
+			r.Put("/domains/{host}/settings", handler.UpdateDomainSettings)
+			r.Delete("/domains/{host}", handler.DeleteDomain)
+		})
 	})
 
+	r.Get("/.well-known/apple-app-site-association", handler.AppleAppSiteAssociation)
+	r.Get("/.well-known/assetlinks.json", handler.AssetLinks)
+	// Short links may sit under a registered path prefix, e.g. /links/abc123.
+	r.Get("/*", handler.RedirectShortLink)
+
 	return r
 }
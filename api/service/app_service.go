@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/api/repository"
+	"dynamic-links-generator/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Paths on short link hosts that are served here rather than by the app.
+var appLinkExcludedPaths = append([]string{".well-known"}, reservedPaths...)
+
+type AppService interface {
+	AppleAppSiteAssociation(ctx context.Context, host string) (*models.AppleAppSiteAssociation, error)
+	AssetLinks(ctx context.Context, host string) ([]models.AssetLink, error)
+}
+
+type appService struct {
+	repo repository.AppRepository
+}
+
+func NewAppService(repo repository.AppRepository) *appService {
+	return &appService{
+		repo: repo,
+	}
+}
+
+func (s *appService) AppleAppSiteAssociation(ctx context.Context, host string) (*models.AppleAppSiteAssociation, error) {
+	host, err := utils.CleanHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host: %w", err)
+	}
+
+	apps, err := s.repo.GetIosApps(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(apps) == 0 {
+		log.Debug().
+			Str("host", host).
+			Msg("No iOS apps registered for host")
+		return nil, apperrors.ErrNoAppsRegistered
+	}
+
+	// Generated paths vary in length with the host's settings and custom
+	// paths can be anything, so every path opens the app except those served
+	// here, which are excluded ahead of the wildcard since iOS takes the first
+	// matching pattern.
+	var paths []string
+	var components []models.AppLinkComponent
+	for _, excluded := range appLinkExcludedPaths {
+		paths = append(paths, "NOT /"+excluded+"/*")
+		components = append(components, models.AppLinkComponent{Path: "/" + excluded + "/*", Exclude: true})
+	}
+	paths = append(paths, "/*")
+	components = append(components, models.AppLinkComponent{Path: "/*"})
+
+	aasa := &models.AppleAppSiteAssociation{
+		AppLinks: models.AppLinks{
+			Apps:    []string{},
+			Details: make([]models.AppLinkDetail, 0, len(apps)),
+		},
+	}
+	for _, app := range apps {
+		appID := app.TeamID + "." + app.BundleID
+		aasa.AppLinks.Details = append(aasa.AppLinks.Details, models.AppLinkDetail{
+			AppIDs:     []string{appID},
+			Components: components,
+			AppID:      appID,
+			Paths:      paths,
+		})
+	}
+
+	return aasa, nil
+}
+
+func (s *appService) AssetLinks(ctx context.Context, host string) ([]models.AssetLink, error) {
+	host, err := utils.CleanHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host: %w", err)
+	}
+
+	apps, err := s.repo.GetAndroidApps(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(apps) == 0 {
+		log.Debug().
+			Str("host", host).
+			Msg("No Android apps registered for host")
+		return nil, apperrors.ErrNoAppsRegistered
+	}
+
+	links := make([]models.AssetLink, 0, len(apps))
+	for _, app := range apps {
+		links = append(links, models.AssetLink{
+			Relation: []string{"delegate_permission/common.handle_all_urls"},
+			Target: models.AssetLinkTarget{
+				Namespace:              "android_app",
+				PackageName:            app.PackageName,
+				SHA256CertFingerprints: app.SHA256CertFingerprints,
+			},
+		})
+	}
+
+	return links, nil
+}
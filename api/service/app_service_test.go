@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAppRepository struct {
+	iosApps     []models.IosApp
+	androidApps []models.AndroidApp
+}
+
+func (f *fakeAppRepository) GetIosApps(_ context.Context, host string) ([]models.IosApp, error) {
+	return f.iosApps, nil
+}
+
+func (f *fakeAppRepository) GetAndroidApps(_ context.Context, host string) ([]models.AndroidApp, error) {
+	return f.androidApps, nil
+}
+
+func TestAppleAppSiteAssociation(t *testing.T) {
+	repo := &fakeAppRepository{iosApps: []models.IosApp{{TeamID: "ABCDE12345", BundleID: "com.example.app"}}}
+	svc := NewAppService(repo)
+
+	aasa, err := svc.AppleAppSiteAssociation(context.Background(), "example.com:9010")
+	assert.NoError(t, err)
+	assert.Len(t, aasa.AppLinks.Details, 1)
+
+	detail := aasa.AppLinks.Details[0]
+	assert.Equal(t, "ABCDE12345.com.example.app", detail.AppID)
+	assert.Equal(t, []string{"ABCDE12345.com.example.app"}, detail.AppIDs)
+	assert.Equal(t, []string{"NOT /.well-known/*", "NOT /v1/*", "/*"}, detail.Paths)
+	assert.Equal(t, []models.AppLinkComponent{
+		{Path: "/.well-known/*", Exclude: true},
+		{Path: "/v1/*", Exclude: true},
+		{Path: "/*"},
+	}, detail.Components)
+
+	_, err = NewAppService(&fakeAppRepository{}).AppleAppSiteAssociation(context.Background(), "example.com")
+	assert.ErrorIs(t, err, apperrors.ErrNoAppsRegistered)
+}
+
+func TestAssetLinks(t *testing.T) {
+	repo := &fakeAppRepository{androidApps: []models.AndroidApp{
+		{PackageName: "com.example.app", SHA256CertFingerprints: []string{"AA:BB"}},
+	}}
+	svc := NewAppService(repo)
+
+	links, err := svc.AssetLinks(context.Background(), "example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.AssetLink{{
+		Relation: []string{"delegate_permission/common.handle_all_urls"},
+		Target: models.AssetLinkTarget{
+			Namespace:              "android_app",
+			PackageName:            "com.example.app",
+			SHA256CertFingerprints: []string{"AA:BB"},
+		},
+	}}, links)
+
+	_, err = NewAppService(&fakeAppRepository{}).AssetLinks(context.Background(), "example.com")
+	assert.ErrorIs(t, err, apperrors.ErrNoAppsRegistered)
+}
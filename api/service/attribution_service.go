@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/api/repository"
+	"dynamic-links-generator/config"
+	"dynamic-links-generator/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+const fingerprintIDLength = 22
+
+// Agreeing device signals a fingerprint match needs at all.
+const minMatchSignals = 1
+
+// A fingerprint match needs at least this many agreeing device signals, on
+// top of the IP prefix, to be reported with DEFAULT rather than WEAK
+// confidence.
+const defaultConfidenceSignals = 3
+
+const noMatchMessage = "No pre-install link matched for this device."
+
+type AttributionService interface {
+	RecordClick(ctx context.Context, info models.DynamicLinkInfo, fp models.ClickFingerprint) (string, error)
+	UpdateClick(ctx context.Context, id string, update models.ClickFingerprintUpdate) error
+	InstallAttribution(ctx context.Context, req models.InstallAttributionRequest, ipPrefix string) (*models.AttributionResponse, error)
+	ReopenAttribution(ctx context.Context, req models.ReopenAttributionRequest) (*models.AttributionResponse, error)
+}
+
+type attributionService struct {
+	repo  repository.AttributionRepository
+	links LinkService
+	cfg   *config.Config
+	now   func() time.Time
+}
+
+func NewAttributionService(repo repository.AttributionRepository, links LinkService, cfg *config.Config) *attributionService {
+	return &attributionService{
+		repo:  repo,
+		links: links,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// RecordClick stores the fingerprint of a click that is about to be sent
+// towards an app, and returns its id. Clicks that lead to no app on the
+// clicking platform are not stored and get an empty id. Fingerprinting is
+// off along with click tracking.
+func (s *attributionService) RecordClick(ctx context.Context, info models.DynamicLinkInfo, fp models.ClickFingerprint) (string, error) {
+	if !s.cfg.ClickTracking || fp.IPPrefix == "" {
+		return "", nil
+	}
+
+	fp.AppID = clickAppID(info, utils.Platform(fp.Platform))
+	if fp.AppID == "" {
+		return "", nil
+	}
+
+	host, path, err := ShortLinkKey(fp.RequestedLink)
+	if err != nil {
+		return "", err
+	}
+	fp.Host = host
+	fp.Path = path
+	fp.ID = utils.GenerateDynamicLinkPath(fingerprintIDLength)
+	fp.ClickedAt = s.now().UTC()
+
+	if err := s.repo.CreateFingerprint(ctx, fp); err != nil {
+		return "", err
+	}
+	return fp.ID, nil
+}
+
+func (s *attributionService) UpdateClick(ctx context.Context, id string, update models.ClickFingerprintUpdate) error {
+	if id == "" || update.ScreenWidth < 0 || update.ScreenHeight < 0 || len(update.Timezone) > 64 {
+		return apperrors.ErrInvalidFormat
+	}
+	return s.repo.UpdateFingerprintBrowserSignals(ctx, id, update)
+}
+
+// InstallAttribution finds the link an app was installed from. A link the
+// app already holds (uniqueMatchLinkToCheck, e.g. from the pasteboard) wins;
+// otherwise the most similar unclaimed click from the same network within
+// the attribution window is claimed.
+func (s *attributionService) InstallAttribution(ctx context.Context, req models.InstallAttributionRequest, ipPrefix string) (*models.AttributionResponse, error) {
+	platform, appID := AppPlatform(req.BundleID, req.PackageName)
+	if appID == "" {
+		return nil, apperrors.ErrMissingAppID
+	}
+
+	if req.UniqueMatchLinkToCheck != "" {
+		info, err := s.links.ResolveDynamicLink(ctx, req.UniqueMatchLinkToCheck)
+		switch {
+		case err != nil:
+			log.Debug().
+				Err(err).
+				Str("link", req.UniqueMatchLinkToCheck).
+				Msg("Unique match link did not resolve, falling back to fingerprint")
+		case !opensApp(*info, platform, appID):
+			log.Debug().
+				Str("link", req.UniqueMatchLinkToCheck).
+				Str("app", appID).
+				Msg("Unique match link is for another app, falling back to fingerprint")
+		default:
+			resp := attributionResponse(req.UniqueMatchLinkToCheck, *info, platform)
+			resp.AttributionConfidence = models.AttributionConfidenceUnique
+			return resp, nil
+		}
+	}
+
+	if ipPrefix == "" {
+		return &models.AttributionResponse{MatchMessage: noMatchMessage}, nil
+	}
+
+	window := time.Duration(s.cfg.AttributionWindowMins) * time.Minute
+	candidates, err := s.repo.FindUnmatchedFingerprints(ctx, ipPrefix, appID, s.now().UTC().Add(-window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load click fingerprints: %w", err)
+	}
+
+	type match struct {
+		fp    models.ClickFingerprint
+		score int
+	}
+	device := installFingerprint(req)
+	var matches []match
+	for _, c := range candidates {
+		// The IP prefix alone is shared by every device behind the same
+		// carrier NAT, so a click must agree on at least one device signal.
+		if score, ok := matchScore(c, device); ok && score >= minMatchSignals {
+			matches = append(matches, match{c, score})
+		}
+	}
+	// Candidates arrive newest first; a stable sort keeps that as the tie
+	// breaker.
+	slices.SortStableFunc(matches, func(a, b match) int { return b.score - a.score })
+
+	for _, m := range matches {
+		// Resolve first so a link that has gone away does not use up the
+		// click.
+		info, err := s.links.ResolveDynamicLink(ctx, m.fp.RequestedLink)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("link", m.fp.RequestedLink).
+				Msg("Matched click no longer resolves")
+			continue
+		}
+
+		claimed, err := s.repo.ClaimFingerprint(ctx, m.fp.ID)
+		if err != nil {
+			return nil, err
+		}
+		if !claimed {
+			continue
+		}
+
+		resp := attributionResponse(m.fp.RequestedLink, *info, platform)
+		resp.AttributionID = m.fp.ID
+		resp.AttributionConfidence = models.AttributionConfidenceWeak
+		if m.score >= defaultConfidenceSignals {
+			resp.AttributionConfidence = models.AttributionConfidenceDefault
+		}
+		return resp, nil
+	}
+
+	return &models.AttributionResponse{MatchMessage: noMatchMessage}, nil
+}
+
+// ReopenAttribution resolves a link that opened an app which was already
+// installed.
+func (s *attributionService) ReopenAttribution(ctx context.Context, req models.ReopenAttributionRequest) (*models.AttributionResponse, error) {
+	platform, _ := AppPlatform(req.BundleID, req.PackageName)
+
+	info, err := s.links.ResolveDynamicLink(ctx, req.RequestedLink)
+	if err != nil {
+		return nil, err
+	}
+	return attributionResponse(req.RequestedLink, *info, platform), nil
+}
+
+// AppPlatform returns the platform and app id an attribution request comes
+// from. iOS SDKs send a bundle id, Android ones a package name.
+func AppPlatform(bundleID, packageName string) (utils.Platform, string) {
+	switch {
+	case bundleID != "":
+		return utils.PlatformIOS, bundleID
+	case packageName != "":
+		return utils.PlatformAndroid, packageName
+	}
+	return utils.PlatformOther, ""
+}
+
+// clickAppID returns the app a click on platform is sent towards.
+func clickAppID(info models.DynamicLinkInfo, platform utils.Platform) string {
+	ios := info.IosParameters
+	switch platform {
+	case utils.PlatformAndroid:
+		return info.AndroidParameters.AndroidPackageName
+	case utils.PlatformIOS:
+		return ios.IosBundleId
+	case utils.PlatformIPad:
+		if ios.IosIpadBundleId != "" {
+			return ios.IosIpadBundleId
+		}
+		return ios.IosBundleId
+	}
+	return ""
+}
+
+// opensApp reports whether a link sends clicks on platform to appID. On iOS
+// the iPad app counts too.
+func opensApp(info models.DynamicLinkInfo, platform utils.Platform, appID string) bool {
+	if platform == utils.PlatformIOS && clickAppID(info, utils.PlatformIPad) == appID {
+		return true
+	}
+	return clickAppID(info, platform) == appID
+}
+
+func installFingerprint(req models.InstallAttributionRequest) models.ClickFingerprint {
+	osVersion := req.OsVersion
+	if osVersion == "" {
+		osVersion = req.IosVersion
+	}
+	return models.ClickFingerprint{
+		DeviceModel:  req.Device.DeviceModelName,
+		OsVersion:    osVersion,
+		Language:     utils.PrimaryLanguage(req.Device.LanguageCode),
+		Timezone:     req.Device.Timezone,
+		ScreenWidth:  req.Device.ScreenResolutionWidth,
+		ScreenHeight: req.Device.ScreenResolutionHeight,
+	}
+}
+
+// matchScore counts the signals a click and an installed device agree on.
+// Signals missing on either side are skipped; any disagreement rules the
+// click out.
+func matchScore(click, device models.ClickFingerprint) (int, bool) {
+	score := 0
+	check := func(known, same bool) bool {
+		if !known {
+			return true
+		}
+		if same {
+			score++
+		}
+		return same
+	}
+
+	ok := check(click.DeviceModel != "" && device.DeviceModel != "", sameDeviceModel(click.DeviceModel, device.DeviceModel)) &&
+		check(click.OsVersion != "" && device.OsVersion != "", sameVersion(click.OsVersion, device.OsVersion)) &&
+		check(click.Language != "" && device.Language != "", click.Language == device.Language) &&
+		check(click.Timezone != "" && device.Timezone != "", click.Timezone == device.Timezone) &&
+		check(click.ScreenWidth > 0 && device.ScreenWidth > 0, sameScreen(click, device))
+	return score, ok
+}
+
+// sameDeviceModel compares the model a browser reported with the one the app
+// reports. Browsers on iOS only give the family, so "iphone" matches
+// "iPhone15,2".
+func sameDeviceModel(browser, app string) bool {
+	return strings.HasPrefix(strings.ToLower(app), strings.ToLower(browser))
+}
+
+// sameVersion compares OS versions on major and, when both have one, minor
+// number, since browsers often report fewer parts than the OS does.
+func sameVersion(a, b string) bool {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	n := min(len(pa), len(pb), 2)
+	return slices.Equal(pa[:n], pb[:n])
+}
+
+// sameScreen ignores orientation.
+func sameScreen(a, b models.ClickFingerprint) bool {
+	return (a.ScreenWidth == b.ScreenWidth && a.ScreenHeight == b.ScreenHeight) ||
+		(a.ScreenWidth == b.ScreenHeight && a.ScreenHeight == b.ScreenWidth)
+}
+
+func attributionResponse(link string, info models.DynamicLinkInfo, platform utils.Platform) *models.AttributionResponse {
+	utm := info.AnalyticsInfo.MarketingParameters
+	resp := &models.AttributionResponse{
+		DeepLink:      info.Link,
+		RequestedLink: link,
+		UtmSource:     utm.UtmSource,
+		UtmMedium:     utm.UtmMedium,
+		UtmCampaign:   utm.UtmCampaign,
+		UtmTerm:       utm.UtmTerm,
+		UtmContent:    utm.UtmContent,
+	}
+	switch platform {
+	case utils.PlatformIOS:
+		resp.AppMinimumVersion = info.IosParameters.IosMinimumVersion
+	case utils.PlatformAndroid:
+		resp.AppMinimumVersion = info.AndroidParameters.AndroidMinPackageVersionCode
+	}
+	return resp
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAttributionRepository struct {
+	mu           sync.Mutex
+	fingerprints []models.ClickFingerprint
+	claimed      map[string]bool
+}
+
+func (f *fakeAttributionRepository) CreateFingerprint(_ context.Context, fp models.ClickFingerprint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fingerprints = append(f.fingerprints, fp)
+	return nil
+}
+
+func (f *fakeAttributionRepository) UpdateFingerprintBrowserSignals(_ context.Context, id string, update models.ClickFingerprintUpdate) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, fp := range f.fingerprints {
+		if fp.ID == id && fp.Timezone == "" && fp.ScreenWidth == 0 {
+			f.fingerprints[i].Timezone = update.Timezone
+			f.fingerprints[i].ScreenWidth = update.ScreenWidth
+			f.fingerprints[i].ScreenHeight = update.ScreenHeight
+		}
+	}
+	return nil
+}
+
+func (f *fakeAttributionRepository) FindUnmatchedFingerprints(_ context.Context, ipPrefix, appID string, since time.Time) ([]models.ClickFingerprint, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result []models.ClickFingerprint
+	for i := len(f.fingerprints) - 1; i >= 0; i-- {
+		fp := f.fingerprints[i]
+		if fp.IPPrefix == ipPrefix && fp.AppID == appID && !fp.ClickedAt.Before(since) && !f.claimed[fp.ID] {
+			result = append(result, fp)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeAttributionRepository) ClaimFingerprint(_ context.Context, id string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.claimed == nil {
+		f.claimed = map[string]bool{}
+	}
+	if f.claimed[id] {
+		return false, nil
+	}
+	f.claimed[id] = true
+	return true, nil
+}
+
+func (f *fakeAttributionRepository) GetFingerprint(_ context.Context, id string) (*models.ClickFingerprint, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, fp := range f.fingerprints {
+		if fp.ID == id {
+			return &fp, nil
+		}
+	}
+	return nil, apperrors.ErrAttributionNotFound
+}
+
+func newTestAttributionService(t *testing.T) (*attributionService, *fakeAttributionRepository, *time.Time) {
+	t.Helper()
+	links := NewLinkService(newFakeLinkRepository(map[string]string{
+		"example.page.link/sale":  "link=https%3A%2F%2Fshop.example%2Fsale&ibi=com.example.ios&apn=com.example.android&imv=2.1&utm_source=newsletter&utm_medium=email&utm_campaign=spring",
+		"example.page.link/other": "link=https%3A%2F%2Fshop.example%2Fother&ibi=com.example.ios",
+		"example.page.link/web":   "link=https%3A%2F%2Fshop.example%2Fweb",
+	}), newFakeDomainRepository(testDomains...), &config.Config{URLScheme: "https"})
+
+	repo := &fakeAttributionRepository{}
+	svc := NewAttributionService(repo, links, &config.Config{ClickTracking: true, AttributionWindowMins: 60})
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	return svc, repo, &now
+}
+
+func TestRecordClick(t *testing.T) {
+	svc, repo, _ := newTestAttributionService(t)
+	ctx := context.Background()
+	info := models.DynamicLinkInfo{
+		IosParameters:     models.IosParameters{IosBundleId: "com.example.ios"},
+		AndroidParameters: models.AndroidParameters{AndroidPackageName: "com.example.android"},
+	}
+
+	id, err := svc.RecordClick(ctx, info, models.ClickFingerprint{RequestedLink: "https://example.page.link/sale", Platform: "IOS", IPPrefix: "203.0.113.0"})
+	assert.NoError(t, err)
+	assert.Len(t, id, fingerprintIDLength)
+	assert.Len(t, repo.fingerprints, 1)
+	assert.Equal(t, "com.example.ios", repo.fingerprints[0].AppID)
+	assert.Equal(t, "example.page.link", repo.fingerprints[0].Host)
+	assert.Equal(t, "sale", repo.fingerprints[0].Path)
+
+	// Desktop clicks lead to no app, and clicks without an address cannot be
+	// matched, so neither is stored.
+	id, err = svc.RecordClick(ctx, info, models.ClickFingerprint{RequestedLink: "https://example.page.link/sale", Platform: "DESKTOP", IPPrefix: "203.0.113.0"})
+	assert.NoError(t, err)
+	assert.Empty(t, id)
+	id, err = svc.RecordClick(ctx, info, models.ClickFingerprint{RequestedLink: "https://example.page.link/sale", Platform: "IOS"})
+	assert.NoError(t, err)
+	assert.Empty(t, id)
+	assert.Len(t, repo.fingerprints, 1)
+
+	svc.cfg = &config.Config{ClickTracking: false}
+	id, err = svc.RecordClick(ctx, info, models.ClickFingerprint{RequestedLink: "https://example.page.link/sale", Platform: "IOS", IPPrefix: "203.0.113.0"})
+	assert.NoError(t, err)
+	assert.Empty(t, id)
+}
+
+func TestInstallAttribution(t *testing.T) {
+	svc, repo, now := newTestAttributionService(t)
+	ctx := context.Background()
+
+	click := func(link, model, osVersion, language string, age time.Duration) models.ClickFingerprint {
+		host, path, _ := ShortLinkKey(link)
+		return models.ClickFingerprint{
+			ID: path + "-" + model, RequestedLink: link, Host: host, Path: path,
+			AppID: "com.example.ios", Platform: "IOS", IPPrefix: "203.0.113.0",
+			DeviceModel: model, OsVersion: osVersion, Language: language,
+			ClickedAt: now.Add(-age),
+		}
+	}
+	repo.fingerprints = []models.ClickFingerprint{
+		click("https://example.page.link/sale", "iphone", "17.4", "en", 10*time.Minute),
+		click("https://example.page.link/other", "ipad", "17.4", "en", 5*time.Minute),
+		click("https://example.page.link/other", "iphone", "16.0", "en", 2*time.Hour),
+	}
+	repo.fingerprints[0].Timezone = "Europe/Berlin"
+
+	req := models.InstallAttributionRequest{
+		BundleID:   "com.example.ios",
+		IosVersion: "17.4.1",
+		Device: models.DeviceFingerprint{
+			DeviceModelName: "iPhone15,2",
+			LanguageCode:    "en-US",
+			Timezone:        "Europe/Berlin",
+		},
+	}
+
+	resp, err := svc.InstallAttribution(ctx, req, "203.0.113.0")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.AttributionResponse{
+		DeepLink:              "https://shop.example/sale",
+		RequestedLink:         "https://example.page.link/sale",
+		AttributionID:         "sale-iphone",
+		AttributionConfidence: models.AttributionConfidenceDefault,
+		AppMinimumVersion:     "2.1",
+		UtmSource:             "newsletter",
+		UtmMedium:             "email",
+		UtmCampaign:           "spring",
+	}, resp)
+
+	// The click is claimed, the iPad click conflicts on model and the last
+	// one is outside the window.
+	resp, err = svc.InstallAttribution(ctx, req, "203.0.113.0")
+	assert.NoError(t, err)
+	assert.Empty(t, resp.DeepLink)
+	assert.Equal(t, noMatchMessage, resp.MatchMessage)
+}
+
+func TestInstallAttribution_WeakMatch(t *testing.T) {
+	svc, repo, now := newTestAttributionService(t)
+	ctx := context.Background()
+	repo.fingerprints = []models.ClickFingerprint{{
+		ID: "c1", RequestedLink: "https://example.page.link/sale", AppID: "com.example.android",
+		Platform: "ANDROID", IPPrefix: "198.51.100.0", Language: "en", ClickedAt: now.Add(-time.Minute),
+	}}
+
+	// Sharing only the network is not a match, and leaves the click for the
+	// device that made it.
+	resp, err := svc.InstallAttribution(ctx, models.InstallAttributionRequest{
+		PackageName: "com.example.android",
+		Device:      models.DeviceFingerprint{DeviceModelName: "Pixel 8"},
+	}, "198.51.100.0")
+	assert.NoError(t, err)
+	assert.Empty(t, resp.DeepLink)
+	assert.Equal(t, noMatchMessage, resp.MatchMessage)
+	assert.False(t, repo.claimed["c1"])
+
+	resp, err = svc.InstallAttribution(ctx, models.InstallAttributionRequest{
+		PackageName: "com.example.android",
+		Device:      models.DeviceFingerprint{DeviceModelName: "Pixel 8", LanguageCode: "en-GB"},
+	}, "198.51.100.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://shop.example/sale", resp.DeepLink)
+	assert.Equal(t, models.AttributionConfidenceWeak, resp.AttributionConfidence)
+}
+
+func TestInstallAttribution_UniqueMatch(t *testing.T) {
+	svc, _, _ := newTestAttributionService(t)
+
+	resp, err := svc.InstallAttribution(context.Background(), models.InstallAttributionRequest{
+		BundleID:               "com.example.ios",
+		UniqueMatchLinkToCheck: "https://example.page.link/sale",
+	}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://shop.example/sale", resp.DeepLink)
+	assert.Equal(t, models.AttributionConfidenceUnique, resp.AttributionConfidence)
+
+	// A link for another app is no unique match.
+	resp, err = svc.InstallAttribution(context.Background(), models.InstallAttributionRequest{
+		PackageName:            "com.example.other",
+		UniqueMatchLinkToCheck: "https://example.page.link/sale",
+	}, "")
+	assert.NoError(t, err)
+	assert.Empty(t, resp.DeepLink)
+	assert.Equal(t, noMatchMessage, resp.MatchMessage)
+
+	_, err = svc.InstallAttribution(context.Background(), models.InstallAttributionRequest{}, "203.0.113.0")
+	assert.ErrorIs(t, err, apperrors.ErrMissingAppID)
+}
+
+func TestInstallAttribution_DeletedLink(t *testing.T) {
+	svc, repo, now := newTestAttributionService(t)
+	repo.fingerprints = []models.ClickFingerprint{{
+		ID: "c1", RequestedLink: "https://example.page.link/missing", AppID: "com.example.ios",
+		Platform: "IOS", IPPrefix: "203.0.113.0", Language: "en", ClickedAt: now.Add(-time.Minute),
+	}}
+
+	resp, err := svc.InstallAttribution(context.Background(), models.InstallAttributionRequest{
+		BundleID: "com.example.ios",
+		Device:   models.DeviceFingerprint{LanguageCode: "en"},
+	}, "203.0.113.0")
+	assert.NoError(t, err)
+	assert.Equal(t, noMatchMessage, resp.MatchMessage)
+	assert.False(t, repo.claimed["c1"])
+}
+
+func TestReopenAttribution(t *testing.T) {
+	svc, _, _ := newTestAttributionService(t)
+
+	resp, err := svc.ReopenAttribution(context.Background(), models.ReopenAttributionRequest{
+		RequestedLink: "https://example.page.link/sale",
+		BundleID:      "com.example.ios",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://shop.example/sale", resp.DeepLink)
+	assert.Equal(t, "spring", resp.UtmCampaign)
+	assert.Empty(t, resp.AttributionConfidence)
+
+	_, err = svc.ReopenAttribution(context.Background(), models.ReopenAttributionRequest{RequestedLink: "https://example.page.link/missing"})
+	assert.ErrorIs(t, err, apperrors.ErrLinkNotFound)
+}
+
+func TestMatchScore(t *testing.T) {
+	click := models.ClickFingerprint{DeviceModel: "iphone", OsVersion: "17.4", Language: "de", ScreenWidth: 390, ScreenHeight: 844}
+
+	tests := []struct {
+		name      string
+		device    models.ClickFingerprint
+		wantScore int
+		wantOK    bool
+	}{
+		{"all agree", models.ClickFingerprint{DeviceModel: "iPhone15,2", OsVersion: "17.4.1", Language: "de", ScreenWidth: 844, ScreenHeight: 390}, 4, true},
+		{"nothing known", models.ClickFingerprint{}, 0, true},
+		{"different os", models.ClickFingerprint{DeviceModel: "iPhone15,2", OsVersion: "17.5"}, 0, false},
+		{"different language", models.ClickFingerprint{Language: "en"}, 0, false},
+		{"different screen", models.ClickFingerprint{ScreenWidth: 430, ScreenHeight: 932}, 0, false},
+		{"major version only", models.ClickFingerprint{OsVersion: "17"}, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := matchScore(click, tt.device)
+			assert.Equal(t, tt.wantOK, ok)
+			if ok {
+				assert.Equal(t, tt.wantScore, score)
+			}
+		})
+	}
+}
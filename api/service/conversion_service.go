@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/api/repository"
+	"dynamic-links-generator/utils"
+)
+
+var (
+	conversionEventPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,63}$`)
+	currencyPattern        = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// Event types the server records itself; apps cannot report them.
+var serverEventTypes = []string{
+	models.EventTypeClick,
+	models.EventTypeRedirect,
+	models.EventTypeAppInstall,
+}
+
+type ConversionService interface {
+	RecordConversion(ctx context.Context, req models.ConversionEventRequest) error
+	LinkConversionStats(ctx context.Context, rawURL string, durationDays int) (*models.ConversionStatsResponse, error)
+	CampaignConversionStats(ctx context.Context, campaign string, durationDays int) (*models.ConversionStatsResponse, error)
+}
+
+type conversionService struct {
+	repo         repository.ConversionRepository
+	attributions repository.AttributionRepository
+	events       repository.EventRepository
+	recorder     EventRecorder
+	links        LinkService
+	now          func() time.Time
+}
+
+func NewConversionService(
+	repo repository.ConversionRepository,
+	attributions repository.AttributionRepository,
+	events repository.EventRepository,
+	recorder EventRecorder,
+	links LinkService,
+) *conversionService {
+	return &conversionService{
+		repo:         repo,
+		attributions: attributions,
+		events:       events,
+		recorder:     recorder,
+		links:        links,
+		now:          time.Now,
+	}
+}
+
+// RecordConversion stores an app-reported event against the short link it
+// references, directly or through an attribution id, together with the
+// link's marketing parameters.
+func (s *conversionService) RecordConversion(ctx context.Context, req models.ConversionEventRequest) error {
+	if !conversionEventPattern.MatchString(req.Event) || slices.Contains(serverEventTypes, req.Event) {
+		return apperrors.ErrInvalidConversionEvent
+	}
+	if (req.ShortLink == "") == (req.AttributionID == "") {
+		return apperrors.ErrInvalidConversionTarget
+	}
+
+	event := models.ConversionEvent{
+		AttributionID: req.AttributionID,
+		EventName:     req.Event,
+		Platform:      conversionPlatform(req.Platform),
+		OccurredAt:    s.now().UTC(),
+	}
+	if req.Value != nil {
+		event.Value = req.Value
+		event.Currency = strings.ToUpper(req.Currency)
+		if !currencyPattern.MatchString(event.Currency) {
+			return apperrors.ErrInvalidConversionValue
+		}
+	}
+	// Apps may report late, but not from the future.
+	if req.OccurredAt != nil && req.OccurredAt.Before(event.OccurredAt) {
+		event.OccurredAt = req.OccurredAt.UTC()
+	}
+
+	link := req.ShortLink
+	if req.AttributionID != "" {
+		fp, err := s.attributions.GetFingerprint(ctx, req.AttributionID)
+		if err != nil {
+			return err
+		}
+		link = fp.RequestedLink
+		if req.Platform == "" {
+			event.Platform = fp.Platform
+		}
+	}
+
+	info, err := s.links.ResolveDynamicLink(ctx, link)
+	if err != nil {
+		return err
+	}
+	if event.Host, event.Path, err = ShortLinkKey(link); err != nil {
+		return err
+	}
+	event.Utm = info.AnalyticsInfo.MarketingParameters
+
+	if err := s.repo.InsertConversion(ctx, event); err != nil {
+		return err
+	}
+
+	// First opens are only known from the app, so they are also fed into
+	// linkStats. Re-opens are already counted by reopenAttribution.
+	if event.EventName == models.EventTypeAppFirstOpen {
+		s.recorder.Record(models.LinkEvent{
+			Host:           event.Host,
+			Path:           event.Path,
+			EventType:      models.EventTypeAppFirstOpen,
+			Source:         models.EventSourceConversion,
+			Platform:       event.Platform,
+			UserAgentClass: utils.UserAgentUnknown,
+			UtmSource:      event.Utm.UtmSource,
+			UtmMedium:      event.Utm.UtmMedium,
+			UtmCampaign:    event.Utm.UtmCampaign,
+			OccurredAt:     event.OccurredAt,
+		})
+	}
+	return nil
+}
+
+func (s *conversionService) LinkConversionStats(ctx context.Context, rawURL string, durationDays int) (*models.ConversionStatsResponse, error) {
+	if durationDays <= 0 {
+		return nil, apperrors.ErrInvalidDurationDays
+	}
+	if _, err := s.links.ResolveShortPath(ctx, rawURL); err != nil {
+		return nil, err
+	}
+	host, path, err := ShortLinkKey(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	since := s.now().UTC().AddDate(0, 0, -durationDays)
+	clicks, err := s.events.CountEvents(ctx, host, path, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count link events: %w", err)
+	}
+	totals, err := s.repo.LinkConversionTotals(ctx, host, path, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversions: %w", err)
+	}
+	return conversionStats(clicks, totals), nil
+}
+
+func (s *conversionService) CampaignConversionStats(ctx context.Context, campaign string, durationDays int) (*models.ConversionStatsResponse, error) {
+	if durationDays <= 0 {
+		return nil, apperrors.ErrInvalidDurationDays
+	}
+	if campaign == "" {
+		return nil, apperrors.ErrInvalidFormat
+	}
+
+	since := s.now().UTC().AddDate(0, 0, -durationDays)
+	clicks, err := s.events.CountCampaignEvents(ctx, campaign, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count campaign events: %w", err)
+	}
+	totals, err := s.repo.CampaignConversionTotals(ctx, campaign, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversions: %w", err)
+	}
+	return conversionStats(clicks, totals), nil
+}
+
+// conversionStats rates each conversion event against the clicks in the
+// same period. Rates are 0 when there were no clicks.
+func conversionStats(counts []models.EventCount, totals []models.ConversionTotal) *models.ConversionStatsResponse {
+	resp := &models.ConversionStatsResponse{Conversions: []models.ConversionStat{}}
+	for _, c := range counts {
+		if c.EventType == models.EventTypeClick {
+			resp.Clicks += c.Count
+		}
+	}
+
+	byEvent := map[string]*models.ConversionStat{}
+	for _, t := range totals {
+		stat := byEvent[t.EventName]
+		if stat == nil {
+			stat = &models.ConversionStat{Event: t.EventName}
+			byEvent[t.EventName] = stat
+		}
+		stat.Count += t.Count
+		if t.Currency == "" {
+			continue
+		}
+		if i := slices.IndexFunc(stat.Values, func(v models.CurrencyValue) bool { return v.Currency == t.Currency }); i >= 0 {
+			stat.Values[i].Total += t.Value
+		} else {
+			stat.Values = append(stat.Values, models.CurrencyValue{Currency: t.Currency, Total: t.Value})
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(byEvent)) {
+		stat := byEvent[name]
+		if resp.Clicks > 0 {
+			stat.ConversionRate = float64(stat.Count) / float64(resp.Clicks)
+		}
+		slices.SortFunc(stat.Values, func(a, b models.CurrencyValue) int { return strings.Compare(a.Currency, b.Currency) })
+		resp.Conversions = append(resp.Conversions, *stat)
+	}
+	return resp
+}
+
+func conversionPlatform(platform string) string {
+	p := utils.Platform(strings.ToUpper(platform))
+	if slices.Contains(utils.Platforms, p) {
+		return string(p)
+	}
+	return string(utils.PlatformOther)
+}
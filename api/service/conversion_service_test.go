@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeConversionRepository struct {
+	mu     sync.Mutex
+	events []models.ConversionEvent
+}
+
+func (f *fakeConversionRepository) InsertConversion(_ context.Context, event models.ConversionEvent) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, event)
+	return nil
+}
+
+func (f *fakeConversionRepository) LinkConversionTotals(_ context.Context, host, path string, since time.Time) ([]models.ConversionTotal, error) {
+	return f.totals(func(e models.ConversionEvent) bool {
+		return e.Host == host && e.Path == path && !e.OccurredAt.Before(since)
+	}), nil
+}
+
+func (f *fakeConversionRepository) CampaignConversionTotals(_ context.Context, campaign string, since time.Time) ([]models.ConversionTotal, error) {
+	return f.totals(func(e models.ConversionEvent) bool {
+		return e.Utm.UtmCampaign == campaign && !e.OccurredAt.Before(since)
+	}), nil
+}
+
+func (f *fakeConversionRepository) totals(match func(models.ConversionEvent) bool) []models.ConversionTotal {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result []models.ConversionTotal
+	for _, e := range f.events {
+		if !match(e) {
+			continue
+		}
+		total := models.ConversionTotal{EventName: e.EventName, Currency: e.Currency, Count: 1}
+		if e.Value != nil {
+			total.Value = *e.Value
+		}
+		result = append(result, total)
+	}
+	return result
+}
+
+type captureEventRecorder struct {
+	events []models.LinkEvent
+}
+
+func (c *captureEventRecorder) Record(event models.LinkEvent) { c.events = append(c.events, event) }
+
+func (c *captureEventRecorder) Close(context.Context) error { return nil }
+
+type conversionTestEnv struct {
+	svc          *conversionService
+	repo         *fakeConversionRepository
+	attributions *fakeAttributionRepository
+	events       *fakeEventRepository
+	recorder     *captureEventRecorder
+	now          time.Time
+}
+
+func newConversionTestEnv() *conversionTestEnv {
+	links := NewLinkService(newFakeLinkRepository(map[string]string{
+		"example.page.link/sale":  "link=https%3A%2F%2Fshop.example%2Fsale&ibi=com.example.ios&utm_source=newsletter&utm_medium=email&utm_campaign=spring",
+		"example.page.link/promo": "link=https%3A%2F%2Fshop.example%2Fpromo&utm_campaign=spring",
+	}), newFakeDomainRepository(testDomains...), &config.Config{URLScheme: "https"})
+
+	env := &conversionTestEnv{
+		repo:         &fakeConversionRepository{},
+		attributions: &fakeAttributionRepository{},
+		events:       &fakeEventRepository{},
+		recorder:     &captureEventRecorder{},
+		now:          time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	env.svc = NewConversionService(env.repo, env.attributions, env.events, env.recorder, links)
+	env.svc.now = func() time.Time { return env.now }
+	return env
+}
+
+func TestRecordConversion(t *testing.T) {
+	env := newConversionTestEnv()
+	ctx := context.Background()
+	value := 19.5
+
+	err := env.svc.RecordConversion(ctx, models.ConversionEventRequest{
+		Event:     "purchase",
+		ShortLink: "https://example.page.link/sale",
+		Value:     &value,
+		Currency:  "eur",
+		Platform:  "ios",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ConversionEvent{{
+		Host:       "example.page.link",
+		Path:       "sale",
+		EventName:  "purchase",
+		Value:      &value,
+		Currency:   "EUR",
+		Platform:   "IOS",
+		Utm:        models.MarketingParameters{UtmSource: "newsletter", UtmMedium: "email", UtmCampaign: "spring"},
+		OccurredAt: env.now,
+	}}, env.repo.events)
+	assert.Empty(t, env.recorder.events)
+}
+
+func TestRecordConversion_AttributionID(t *testing.T) {
+	env := newConversionTestEnv()
+	env.attributions.fingerprints = []models.ClickFingerprint{{
+		ID: "c1", RequestedLink: "https://example.page.link/sale", Platform: "IPAD",
+	}}
+	reportedAt := env.now.Add(-time.Hour)
+
+	err := env.svc.RecordConversion(context.Background(), models.ConversionEventRequest{
+		Event:         models.EventTypeAppFirstOpen,
+		AttributionID: "c1",
+		OccurredAt:    &reportedAt,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, env.repo.events, 1)
+	assert.Equal(t, "c1", env.repo.events[0].AttributionID)
+	assert.Equal(t, "IPAD", env.repo.events[0].Platform)
+	assert.Equal(t, reportedAt, env.repo.events[0].OccurredAt)
+
+	// First opens also show up in linkStats.
+	assert.Len(t, env.recorder.events, 1)
+	assert.Equal(t, models.EventTypeAppFirstOpen, env.recorder.events[0].EventType)
+	assert.Equal(t, "spring", env.recorder.events[0].UtmCampaign)
+}
+
+func TestRecordConversion_Errors(t *testing.T) {
+	env := newConversionTestEnv()
+	value := 1.0
+
+	tests := []struct {
+		name    string
+		req     models.ConversionEventRequest
+		wantErr error
+	}{
+		{"reserved event", models.ConversionEventRequest{Event: "CLICK", ShortLink: "https://example.page.link/sale"}, apperrors.ErrInvalidConversionEvent},
+		{"bad event name", models.ConversionEventRequest{Event: "add to cart", ShortLink: "https://example.page.link/sale"}, apperrors.ErrInvalidConversionEvent},
+		{"no target", models.ConversionEventRequest{Event: "purchase"}, apperrors.ErrInvalidConversionTarget},
+		{"both targets", models.ConversionEventRequest{Event: "purchase", ShortLink: "https://example.page.link/sale", AttributionID: "c1"}, apperrors.ErrInvalidConversionTarget},
+		{"value without currency", models.ConversionEventRequest{Event: "purchase", ShortLink: "https://example.page.link/sale", Value: &value}, apperrors.ErrInvalidConversionValue},
+		{"unknown attribution", models.ConversionEventRequest{Event: "purchase", AttributionID: "missing"}, apperrors.ErrAttributionNotFound},
+		{"unknown link", models.ConversionEventRequest{Event: "purchase", ShortLink: "https://example.page.link/missing"}, apperrors.ErrLinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := env.svc.RecordConversion(context.Background(), tt.req)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+	assert.Empty(t, env.repo.events)
+}
+
+func TestConversionStats(t *testing.T) {
+	env := newConversionTestEnv()
+	ctx := context.Background()
+
+	click := func(path string) models.LinkEvent {
+		return models.LinkEvent{Host: "example.page.link", Path: path, EventType: models.EventTypeClick, UtmCampaign: "spring", OccurredAt: env.now.Add(-time.Hour)}
+	}
+	env.events.batches = [][]models.LinkEvent{{click("sale"), click("sale"), click("sale"), click("sale"), click("promo")}}
+
+	v1, v2 := 10.0, 5.5
+	for _, req := range []models.ConversionEventRequest{
+		{Event: "purchase", ShortLink: "https://example.page.link/sale", Value: &v1, Currency: "EUR"},
+		{Event: "purchase", ShortLink: "https://example.page.link/sale", Value: &v2, Currency: "EUR"},
+		{Event: "APP_FIRST_OPEN", ShortLink: "https://example.page.link/promo"},
+	} {
+		assert.NoError(t, env.svc.RecordConversion(ctx, req))
+	}
+
+	stats, err := env.svc.LinkConversionStats(ctx, "https://example.page.link/sale", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ConversionStatsResponse{
+		Clicks: 4,
+		Conversions: []models.ConversionStat{{
+			Event: "purchase", Count: 2, ConversionRate: 0.5,
+			Values: []models.CurrencyValue{{Currency: "EUR", Total: 15.5}},
+		}},
+	}, stats)
+
+	stats, err = env.svc.CampaignConversionStats(ctx, "spring", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), stats.Clicks)
+	assert.Equal(t, []string{"APP_FIRST_OPEN", "purchase"}, []string{stats.Conversions[0].Event, stats.Conversions[1].Event})
+	assert.InDelta(t, 0.2, stats.Conversions[0].ConversionRate, 1e-9)
+
+	_, err = env.svc.CampaignConversionStats(ctx, "spring", 0)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDurationDays)
+	_, err = env.svc.CampaignConversionStats(ctx, "", 7)
+	assert.ErrorIs(t, err, apperrors.ErrInvalidFormat)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/api/repository"
+	"dynamic-links-generator/config"
+	"dynamic-links-generator/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+type DomainService interface {
+	ListDomains(ctx context.Context) ([]models.Domain, error)
+	RegisterDomain(ctx context.Context, host string, settings models.DomainSettings) (*models.Domain, error)
+	UpdateDomainSettings(ctx context.Context, host string, settings models.DomainSettings) (*models.Domain, error)
+	DeleteDomain(ctx context.Context, host string) error
+}
+
+type domainService struct {
+	repo repository.DomainRepository
+	cfg  *config.Config
+}
+
+func NewDomainService(repo repository.DomainRepository, cfg *config.Config) *domainService {
+	return &domainService{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+func (s *domainService) ListDomains(ctx context.Context) ([]models.Domain, error) {
+	return s.repo.ListDomains(ctx)
+}
+
+func (s *domainService) RegisterDomain(ctx context.Context, host string, settings models.DomainSettings) (*models.Domain, error) {
+	host, err := utils.CleanHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrHostInvalid, err)
+	}
+	// Preview variants are served by their base domain and cannot be
+	// registered on their own.
+	if IsPreviewHost(host) {
+		return nil, fmt.Errorf("%w: %s is a preview host, register %s instead", apperrors.ErrHostInvalid, host, removePreviewFromHost(host))
+	}
+
+	settings, err = s.validateDomainSettings(host, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := s.repo.CreateDomain(ctx, host, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("host", host).
+		Msg("Domain registered")
+	return domain, nil
+}
+
+func (s *domainService) UpdateDomainSettings(ctx context.Context, host string, settings models.DomainSettings) (*models.Domain, error) {
+	host, err := utils.CleanHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", apperrors.ErrHostInvalid, err)
+	}
+	settings, err = s.validateDomainSettings(host, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := s.repo.UpdateDomainSettings(ctx, host, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("host", host).
+		Msg("Domain settings updated")
+	return domain, nil
+}
+
+// validateDomainSettings checks settings as they would apply to host, on top
+// of the process config.
+func (s *domainService) validateDomainSettings(host string, settings models.DomainSettings) (models.DomainSettings, error) {
+	settings = settings.Normalize()
+	if err := settings.ValidateOverride(configSettings(s.cfg, host)); err != nil {
+		return settings, fmt.Errorf("%w: %v", apperrors.ErrInvalidDomainSettings, err)
+	}
+	for _, prefix := range settings.PathPrefixes {
+		first, _, _ := strings.Cut(prefix, "/")
+		if slices.Contains(reservedPaths, strings.ToLower(first)) {
+			return settings, fmt.Errorf("%w: path prefix %q is reserved", apperrors.ErrInvalidDomainSettings, prefix)
+		}
+	}
+	return settings, nil
+}
+
+func (s *domainService) DeleteDomain(ctx context.Context, host string) error {
+	host, err := utils.CleanHost(host)
+	if err != nil {
+		return fmt.Errorf("%w: %v", apperrors.ErrHostInvalid, err)
+	}
+
+	if err := s.repo.DeleteDomain(ctx, host); err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("host", host).
+		Msg("Domain removed")
+	return nil
+}
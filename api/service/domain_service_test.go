@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeDomainRepository struct {
+	mu      sync.Mutex
+	domains map[string]models.Domain
+}
+
+func newFakeDomainRepository(hosts ...string) *fakeDomainRepository {
+	f := &fakeDomainRepository{domains: map[string]models.Domain{}}
+	for _, host := range hosts {
+		f.domains[host] = models.Domain{Host: host}
+	}
+	return f
+}
+
+func (f *fakeDomainRepository) ListDomains(_ context.Context) ([]models.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	domains := []models.Domain{}
+	for _, domain := range f.domains {
+		domains = append(domains, domain)
+	}
+	sort.Slice(domains, func(i, j int) bool { return domains[i].Host < domains[j].Host })
+	return domains, nil
+}
+
+func (f *fakeDomainRepository) GetDomain(_ context.Context, host string) (*models.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	domain, ok := f.domains[host]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrDomainNotRegistered, host)
+	}
+	return &domain, nil
+}
+
+func (f *fakeDomainRepository) CreateDomain(_ context.Context, host string, settings models.DomainSettings) (*models.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.domains[host]; ok {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrDomainAlreadyExists, host)
+	}
+	domain := models.Domain{Host: host, Settings: settings}
+	f.domains[host] = domain
+	return &domain, nil
+}
+
+func (f *fakeDomainRepository) UpdateDomainSettings(_ context.Context, host string, settings models.DomainSettings) (*models.Domain, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	domain, ok := f.domains[host]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", apperrors.ErrDomainNotRegistered, host)
+	}
+	domain.Settings = settings
+	f.domains[host] = domain
+	return &domain, nil
+}
+
+func (f *fakeDomainRepository) DeleteDomain(_ context.Context, host string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.domains[host]; !ok {
+		return fmt.Errorf("%w: %s", apperrors.ErrDomainNotRegistered, host)
+	}
+	delete(f.domains, host)
+	return nil
+}
+
+func TestRegisterDomain(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		wantHost string
+		wantErr  error
+	}{
+		{"plain host", "example.page.link", "example.page.link", nil},
+		{"url with scheme and path", "https://Example.page.link/abc", "example.page.link", nil},
+		{"unicode host", "münchen.example", "xn--mnchen-3ya.example", nil},
+		{"already registered", "taken.page.link", "", apperrors.ErrDomainAlreadyExists},
+		{"preview prefix", "preview.example.page.link", "", apperrors.ErrHostInvalid},
+		{"preview suffix", "acme-preview.page.link", "", apperrors.ErrHostInvalid},
+		{"unparsable", "not a host", "", apperrors.ErrHostInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewDomainService(newFakeDomainRepository("taken.page.link"), testDomainConfig)
+
+			domain, err := svc.RegisterDomain(context.Background(), tt.host, models.DomainSettings{})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantHost, domain.Host)
+		})
+	}
+}
+
+var testDomainConfig = &config.Config{ShortPathLength: 6, UnguessablePathLength: 10}
+
+func TestRegisterDomain_Settings(t *testing.T) {
+	svc := NewDomainService(newFakeDomainRepository("example.page.link"), testDomainConfig)
+
+	_, err := svc.RegisterDomain(context.Background(), "brand.page.link", models.DomainSettings{PathAlphabet: "a"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{DefaultSuffixOption: "LONG"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	// CUSTOM needs a customPath, so it cannot be a default.
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{DefaultSuffixOption: "CUSTOM"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{ShortPathLength: 1})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{UnguessablePathLength: 4})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{UnguessablePathLength: 12, PathAlphabet: "0123456789"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{ShortPathLength: 12, UnguessablePathLength: 10})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	// Overrides are checked against the settings the host ends up with: the
+	// global unguessable length over a two letter alphabet, and a short path
+	// longer than the global unguessable one.
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{PathAlphabet: "ab"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{ShortPathLength: 12})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{PathPrefixes: []string{"v1/links"}})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	_, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{PathPrefixes: []string{"a b"}})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidDomainSettings)
+
+	domain, err := svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{PathPrefixes: []string{"/links/"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"links"}, domain.Settings.PathPrefixes)
+
+	_, err = svc.UpdateDomainSettings(context.Background(), "missing.page.link", models.DomainSettings{})
+	assert.ErrorIs(t, err, apperrors.ErrDomainNotRegistered)
+
+	domain, err = svc.UpdateDomainSettings(context.Background(), "example.page.link", models.DomainSettings{ShortPathLength: 8})
+	assert.NoError(t, err)
+	assert.Equal(t, 8, domain.Settings.ShortPathLength)
+}
+
+func TestDeleteDomain(t *testing.T) {
+	repo := newFakeDomainRepository("example.page.link")
+	svc := NewDomainService(repo, testDomainConfig)
+
+	assert.NoError(t, svc.DeleteDomain(context.Background(), "EXAMPLE.page.link"))
+	assert.ErrorIs(t, svc.DeleteDomain(context.Background(), "example.page.link"), apperrors.ErrDomainNotRegistered)
+
+	domains, err := svc.ListDomains(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, domains)
+}
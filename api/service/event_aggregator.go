@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"dynamic-links-generator/api/repository"
+	"dynamic-links-generator/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+// How far before the last run's watermark each run re-reads, to pick up
+// events from transactions that were still open at the time.
+const rollupOverlap = 5 * time.Minute
+
+const rollupTimeout = 5 * time.Minute
+
+// EventAggregator rolls raw link events up into the hourly and daily counters
+// the stats APIs read, and purges raw events past the retention period.
+type EventAggregator interface {
+	Close(ctx context.Context) error
+}
+
+type periodicEventAggregator struct {
+	repo          repository.RollupRepository
+	interval      time.Duration
+	retentionDays int
+	now           func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewEventAggregator(repo repository.RollupRepository, cfg *config.Config) EventAggregator {
+	if !cfg.ClickTracking {
+		return noopEventAggregator{}
+	}
+
+	a := newPeriodicEventAggregator(repo, cfg)
+	go a.run()
+	return a
+}
+
+func newPeriodicEventAggregator(repo repository.RollupRepository, cfg *config.Config) *periodicEventAggregator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &periodicEventAggregator{
+		repo:          repo,
+		interval:      time.Duration(cfg.RollupIntervalSeconds) * time.Second,
+		retentionDays: cfg.EventRetentionDays,
+		now:           time.Now,
+		ctx:           ctx,
+		cancel:        cancel,
+		done:          make(chan struct{}),
+	}
+}
+
+// Close stops the aggregator, cancelling a run in progress; the rollups are
+// left as of the last completed run.
+func (a *periodicEventAggregator) Close(ctx context.Context) error {
+	a.cancel()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *periodicEventAggregator) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		a.aggregate()
+
+		select {
+		case <-ticker.C:
+		case <-a.ctx.Done():
+			return
+		}
+	}
+}
+
+func (a *periodicEventAggregator) aggregate() {
+	ctx, cancel := context.WithTimeout(a.ctx, rollupTimeout)
+	defer cancel()
+
+	horizon := a.retentionCutoff()
+	ran, err := a.repo.AggregateEvents(ctx, horizon, rollupOverlap)
+	if err != nil {
+		if a.ctx.Err() == nil {
+			log.Error().Err(err).Msg("Failed to aggregate link events")
+		}
+		return
+	}
+	if !ran {
+		log.Debug().Msg("Link event aggregation is running on another instance")
+		return
+	}
+
+	// Purge only what a completed run has rolled up.
+	if a.retentionDays <= 0 {
+		return
+	}
+	purged, err := a.repo.PurgeEvents(ctx, horizon)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to purge link events")
+		return
+	}
+	if purged > 0 {
+		log.Info().
+			Int64("events", purged).
+			Time("before", horizon).
+			Msg("Purged raw link events")
+	}
+}
+
+// retentionCutoff is the UTC midnight before which raw events are purged and
+// rollups are final. Without a retention period everything is kept.
+func (a *periodicEventAggregator) retentionCutoff() time.Time {
+	if a.retentionDays <= 0 {
+		return time.Time{}
+	}
+	return a.now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -a.retentionDays)
+}
+
+type noopEventAggregator struct{}
+
+func (noopEventAggregator) Close(context.Context) error { return nil }
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"dynamic-links-generator/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRollupRepository struct {
+	mu       sync.Mutex
+	locked   bool
+	horizons []time.Time
+	purges   []time.Time
+}
+
+func (f *fakeRollupRepository) AggregateEvents(_ context.Context, horizon time.Time, _ time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.locked {
+		return false, nil
+	}
+	f.horizons = append(f.horizons, horizon)
+	return true, nil
+}
+
+func (f *fakeRollupRepository) PurgeEvents(_ context.Context, before time.Time) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.purges = append(f.purges, before)
+	return 1, nil
+}
+
+func (f *fakeRollupRepository) runs() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.horizons)
+}
+
+func TestEventAggregator_PurgesAfterAggregating(t *testing.T) {
+	repo := &fakeRollupRepository{}
+	a := newPeriodicEventAggregator(repo, &config.Config{RollupIntervalSeconds: 60, EventRetentionDays: 30})
+	a.now = func() time.Time { return time.Date(2024, 5, 15, 13, 45, 0, 0, time.UTC) }
+
+	a.aggregate()
+
+	cutoff := time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []time.Time{cutoff}, repo.horizons)
+	assert.Equal(t, []time.Time{cutoff}, repo.purges)
+}
+
+func TestEventAggregator_NoRetention(t *testing.T) {
+	repo := &fakeRollupRepository{}
+	a := newPeriodicEventAggregator(repo, &config.Config{RollupIntervalSeconds: 60})
+
+	a.aggregate()
+
+	assert.Equal(t, []time.Time{{}}, repo.horizons)
+	assert.Empty(t, repo.purges)
+}
+
+func TestEventAggregator_SkipsWhenLocked(t *testing.T) {
+	repo := &fakeRollupRepository{locked: true}
+	a := newPeriodicEventAggregator(repo, &config.Config{RollupIntervalSeconds: 60, EventRetentionDays: 30})
+
+	a.aggregate()
+
+	assert.Empty(t, repo.horizons)
+	assert.Empty(t, repo.purges)
+}
+
+func TestEventAggregator_RunsUntilClosed(t *testing.T) {
+	repo := &fakeRollupRepository{}
+	aggregator := NewEventAggregator(repo, &config.Config{ClickTracking: true, RollupIntervalSeconds: 1})
+
+	// The first run starts right away rather than after one interval.
+	assert.Eventually(t, func() bool { return repo.runs() == 1 }, time.Second, 5*time.Millisecond)
+	assert.NoError(t, aggregator.Close(context.Background()))
+
+	runs := repo.runs()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, runs, repo.runs())
+}
+
+func TestEventAggregator_Disabled(t *testing.T) {
+	aggregator := NewEventAggregator(&fakeRollupRepository{}, &config.Config{ClickTracking: false, RollupIntervalSeconds: 60})
+	assert.IsType(t, noopEventAggregator{}, aggregator)
+	assert.NoError(t, aggregator.Close(context.Background()))
+}
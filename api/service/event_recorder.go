@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/api/repository"
+	"dynamic-links-generator/config"
+
+	"github.com/rs/zerolog/log"
+)
+
+// EventRecorder collects link events off the request path. Record never
+// blocks; Close flushes whatever is still buffered.
+type EventRecorder interface {
+	Record(event models.LinkEvent)
+	Close(ctx context.Context) error
+}
+
+const eventWriteTimeout = 10 * time.Second
+
+type asyncEventRecorder struct {
+	repo      repository.EventRepository
+	events    chan models.LinkEvent
+	batchSize int
+	interval  time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+	dropped atomic.Int64
+}
+
+func NewEventRecorder(repo repository.EventRepository, cfg *config.Config) EventRecorder {
+	if !cfg.ClickTracking {
+		return noopEventRecorder{}
+	}
+
+	r := &asyncEventRecorder{
+		repo:      repo,
+		events:    make(chan models.LinkEvent, max(cfg.EventBufferSize, 1)),
+		batchSize: max(cfg.EventBatchSize, 1),
+		interval:  time.Duration(max(cfg.EventFlushIntervalMs, 1)) * time.Millisecond,
+		done:      make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+func (r *asyncEventRecorder) Record(event models.LinkEvent) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+
+	select {
+	case r.events <- event:
+	default:
+		// Dropping keeps redirects fast when the database falls behind.
+		if n := r.dropped.Add(1); n == 1 || n%1000 == 0 {
+			log.Warn().
+				Int64("dropped", n).
+				Msg("Event buffer full, dropping link events")
+		}
+	}
+}
+
+func (r *asyncEventRecorder) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.events)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *asyncEventRecorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	batch := make([]models.LinkEvent, 0, r.batchSize)
+	for {
+		select {
+		case event, ok := <-r.events:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= r.batchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (r *asyncEventRecorder) flush(batch []models.LinkEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventWriteTimeout)
+	defer cancel()
+
+	if err := r.repo.InsertEvents(ctx, batch); err != nil {
+		log.Error().
+			Err(err).
+			Int("events", len(batch)).
+			Msg("Failed to write link events")
+		return
+	}
+
+	log.Debug().
+		Int("events", len(batch)).
+		Msg("Link events written")
+}
+
+type noopEventRecorder struct{}
+
+func (noopEventRecorder) Record(models.LinkEvent) {}
+
+func (noopEventRecorder) Close(context.Context) error { return nil }
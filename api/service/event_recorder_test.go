@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeEventRepository struct {
+	mu      sync.Mutex
+	batches [][]models.LinkEvent
+	block   chan struct{}
+}
+
+func (f *fakeEventRepository) InsertEvents(_ context.Context, events []models.LinkEvent) error {
+	if f.block != nil {
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, append([]models.LinkEvent(nil), events...))
+	return nil
+}
+
+func (f *fakeEventRepository) CountEvents(_ context.Context, host, path string, since time.Time) ([]models.EventCount, error) {
+	return f.countEvents(func(e models.LinkEvent) bool {
+		return e.Host == host && e.Path == path && !e.OccurredAt.Before(since)
+	})
+}
+
+func (f *fakeEventRepository) CountCampaignEvents(_ context.Context, campaign string, since time.Time) ([]models.EventCount, error) {
+	return f.countEvents(func(e models.LinkEvent) bool {
+		return e.UtmCampaign == campaign && !e.OccurredAt.Before(since)
+	})
+}
+
+func (f *fakeEventRepository) countEvents(match func(models.LinkEvent) bool) ([]models.EventCount, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := map[[2]string]int64{}
+	for _, batch := range f.batches {
+		for _, e := range batch {
+			if match(e) {
+				counts[[2]string{e.Platform, e.EventType}]++
+			}
+		}
+	}
+
+	var result []models.EventCount
+	for key, n := range counts {
+		result = append(result, models.EventCount{Platform: key[0], EventType: key[1], Count: n})
+	}
+	return result, nil
+}
+
+func (f *fakeEventRepository) written() []models.LinkEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var all []models.LinkEvent
+	for _, batch := range f.batches {
+		all = append(all, batch...)
+	}
+	return all
+}
+
+func eventConfig(bufferSize, batchSize, flushMs int) *config.Config {
+	return &config.Config{
+		ClickTracking:        true,
+		EventBufferSize:      bufferSize,
+		EventBatchSize:       batchSize,
+		EventFlushIntervalMs: flushMs,
+	}
+}
+
+func TestEventRecorder_FlushesFullBatch(t *testing.T) {
+	repo := &fakeEventRepository{}
+	recorder := NewEventRecorder(repo, eventConfig(10, 2, 60000))
+	defer recorder.Close(context.Background())
+
+	recorder.Record(models.LinkEvent{Path: "a"})
+	recorder.Record(models.LinkEvent{Path: "b"})
+
+	assert.Eventually(t, func() bool { return len(repo.written()) == 2 }, time.Second, 5*time.Millisecond)
+}
+
+func TestEventRecorder_FlushesOnInterval(t *testing.T) {
+	repo := &fakeEventRepository{}
+	recorder := NewEventRecorder(repo, eventConfig(10, 100, 10))
+	defer recorder.Close(context.Background())
+
+	recorder.Record(models.LinkEvent{Path: "a"})
+
+	assert.Eventually(t, func() bool { return len(repo.written()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestEventRecorder_CloseFlushesBuffered(t *testing.T) {
+	repo := &fakeEventRepository{}
+	recorder := NewEventRecorder(repo, eventConfig(10, 100, 60000))
+
+	recorder.Record(models.LinkEvent{Path: "a"})
+	recorder.Record(models.LinkEvent{Path: "b"})
+	assert.NoError(t, recorder.Close(context.Background()))
+	assert.Len(t, repo.written(), 2)
+
+	recorder.Record(models.LinkEvent{Path: "c"})
+	assert.NoError(t, recorder.Close(context.Background()))
+	assert.Len(t, repo.written(), 2)
+}
+
+func TestEventRecorder_DropsWhenFull(t *testing.T) {
+	repo := &fakeEventRepository{block: make(chan struct{})}
+	recorder := NewEventRecorder(repo, eventConfig(1, 1, 60000))
+
+	// The first event is taken by the writer, which then blocks on the
+	// repository; the second fills the buffer and the rest are dropped.
+	recorder.Record(models.LinkEvent{Path: "a"})
+	assert.Eventually(t, func() bool {
+		return len(recorder.(*asyncEventRecorder).events) == 0
+	}, time.Second, time.Millisecond)
+	for range 5 {
+		recorder.Record(models.LinkEvent{Path: "b"})
+	}
+	close(repo.block)
+
+	assert.NoError(t, recorder.Close(context.Background()))
+	assert.Len(t, repo.written(), 2)
+	assert.Equal(t, int64(4), recorder.(*asyncEventRecorder).dropped.Load())
+}
+
+func TestEventRecorder_Disabled(t *testing.T) {
+	repo := &fakeEventRepository{}
+	recorder := NewEventRecorder(repo, &config.Config{ClickTracking: false})
+
+	recorder.Record(models.LinkEvent{Path: "a"})
+	assert.NoError(t, recorder.Close(context.Background()))
+	assert.Empty(t, repo.written())
+}
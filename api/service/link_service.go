@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"net/url"
+	"slices"
 	"strings"
 
 	"dynamic-links-generator/api/apperrors"
@@ -21,18 +24,37 @@ type LinkService interface {
 	CreateDynamicLink(ctx context.Context, params models.CreateDynamicLinkRequest) (*models.ShortLinkResponse, error)
 	ParseLongDynamicLink(longLink string) (models.CreateDynamicLinkRequest, error)
 	ResolveShortPath(ctx context.Context, rawURL string) (*models.LongLinkResponse, error)
+	ResolveDynamicLink(ctx context.Context, rawURL string) (*models.DynamicLinkInfo, error)
 	PrepareDynamicLinkRequest(input map[string]any) (models.CreateDynamicLinkRequest, error)
 }
 
+// Long link query keys that map onto DynamicLinkInfo or Suffix fields.
+var knownLinkParams = []string{
+	"link",
+	"apn", "afl", "amv",
+	"ibi", "ifl", "ius", "ipfl", "ipbi", "isi", "imv",
+	"ofl",
+	"efr",
+	"st", "sd", "si",
+	"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content",
+	"at", "ct", "mt", "pt",
+	"path", "customPath",
+}
+
+// Top-level paths served by NewRouter that a custom path must not shadow.
+var reservedPaths = []string{"v1"}
+
 type linkService struct {
-	repo repository.LinkRepository
-	cfg  *config.Config
+	repo    repository.LinkRepository
+	domains repository.DomainRepository
+	cfg     *config.Config
 }
 
-func NewLinkService(repo repository.LinkRepository, cfg *config.Config) *linkService {
+func NewLinkService(repo repository.LinkRepository, domains repository.DomainRepository, cfg *config.Config) *linkService {
 	return &linkService{
-		repo: repo,
-		cfg:  cfg,
+		repo:    repo,
+		domains: domains,
+		cfg:     cfg,
 	}
 }
 
@@ -40,13 +62,14 @@ func (s *linkService) getLongLinkFromHostAndPath(
 	ctx context.Context,
 	host string,
 	path string,
+	settings models.DomainSettings,
 ) (*models.LongLinkResponse, error) {
 	rawQueryStr, err := s.repo.GetQueryParamsByHostAndPath(ctx, host, path)
 	if err != nil {
 		return nil, err
 	}
 
-	longLink := fmt.Sprintf("%s://%s/%s", s.cfg.URLScheme, host, path)
+	longLink := fmt.Sprintf("%s://%s/%s", settings.URLScheme, host, path)
 	if rawQueryStr != "" {
 		longLink += "?" + rawQueryStr
 	}
@@ -68,7 +91,7 @@ func (s *linkService) CreateDynamicLink(ctx context.Context, params models.Creat
 		Str("params", fmt.Sprintf("%+v", params)).
 		Msg("Dynamic link parameters")
 
-	host, err := utils.CleanHost(params.DynamicLinkInfo.Host)
+	host, prefix, err := utils.SplitURLPrefix(LinkDomain(params.DynamicLinkInfo))
 	if err != nil {
 		log.Error().
 			Str("host", params.DynamicLinkInfo.Host).
@@ -76,13 +99,58 @@ func (s *linkService) CreateDynamicLink(ctx context.Context, params models.Creat
 		return nil, fmt.Errorf("invalid host: %w", err)
 	}
 
-	if !utils.IsDomainAllowed(s.cfg.DomainAllowList, params.DynamicLinkInfo.Link) {
+	domain, err := s.registeredDomain(ctx, host)
+	if err != nil {
+		log.Error().
+			Str("host", params.DynamicLinkInfo.Host).
+			Msg("Host is not a registered domain")
+		return nil, err
+	}
+	host = domain.Host
+	settings := domainSettings(s.cfg, domain)
+
+	if prefix != "" && !slices.Contains(settings.PathPrefixes, prefix) {
+		log.Error().
+			Str("host", host).
+			Str("prefix", prefix).
+			Msg("Path prefix is not registered for host")
+		return nil, fmt.Errorf("%w: %s/%s", apperrors.ErrPathPrefixNotRegistered, host, prefix)
+	}
+
+	if !params.SkipDefaultAppParameters {
+		defaulted := applyDefaultAppParameters(&params.DynamicLinkInfo, settings.DefaultAppParameters)
+		for _, param := range defaulted {
+			warnings = append(warnings, models.Warning{
+				WarningCode:    "DEFAULTED_PARAM",
+				WarningMessage: fmt.Sprintf("Param '%s' was not specified and was filled in from the defaults for host '%s'.", param, host),
+			})
+		}
+	}
+
+	if u, err := url.Parse(params.DynamicLinkInfo.Link); err == nil && utils.IsMixedScriptHost(u.Hostname()) {
+		log.Error().
+			Str("link", params.DynamicLinkInfo.Link).
+			Msg("Link host mixes scripts")
+		return nil, apperrors.ErrMixedScriptLinkHost
+	}
+
+	if !utils.IsDomainAllowed(settings.DomainAllowList, params.DynamicLinkInfo.Link) {
 		log.Error().
 			Str("link", params.DynamicLinkInfo.Link).
 			Msg("Domain link not in allow list")
 		return nil, apperrors.ErrDomainLinkNotAllowed
 	}
 
+	for _, fallback := range fallbackLinks(params.DynamicLinkInfo) {
+		if !utils.IsDomainAllowed(s.fallbackAllowList(settings), fallback.link) {
+			log.Error().
+				Str("param", fallback.param).
+				Str("link", fallback.link).
+				Msg("Fallback link not in allow list")
+			return nil, &apperrors.ParamError{Param: fallback.param, Err: apperrors.ErrFallbackNotAllowed}
+		}
+	}
+
 	isi := params.DynamicLinkInfo.IosParameters.IosAppStoreId
 
 	if isi != "" {
@@ -104,12 +172,23 @@ func (s *linkService) CreateDynamicLink(ctx context.Context, params models.Creat
 	addParam("afl", params.DynamicLinkInfo.AndroidParameters.AndroidFallbackLink)
 	addParam("amv", params.DynamicLinkInfo.AndroidParameters.AndroidMinPackageVersionCode)
 
-	addParam("ifl", params.DynamicLinkInfo.IosParameters.IosFallbackLink)
-	addParam("ipfl", params.DynamicLinkInfo.IosParameters.IosIpadFallbackLink)
+	ios := params.DynamicLinkInfo.IosParameters
+	addParam("ibi", ios.IosBundleId)
+	addParam("ifl", ios.IosFallbackLink)
+	addParam("ius", ios.IosCustomScheme)
+	addParam("ipfl", ios.IosIpadFallbackLink)
+	addParam("ipbi", ios.IosIpadBundleId)
 	addParam("isi", isi)
+	addParam("imv", ios.IosMinimumVersion)
+
+	warnings = append(warnings, iosParameterWarnings(ios)...)
 
 	addParam("ofl", params.DynamicLinkInfo.OtherPlatformParameters.FallbackURL)
 
+	if params.DynamicLinkInfo.NavigationInfo.EnableForcedRedirect {
+		queryParams.Add("efr", "1")
+	}
+
 	addParam("st", params.DynamicLinkInfo.SocialMetaTagInfo.SocialTitle)
 	addParam("sd", params.DynamicLinkInfo.SocialMetaTagInfo.SocialDescription)
 
@@ -186,8 +265,39 @@ func (s *linkService) CreateDynamicLink(ctx context.Context, params models.Creat
 	addParam("ct", params.DynamicLinkInfo.AnalyticsInfo.ItunesConnectAnalytics.Ct)
 	addParam("mt", params.DynamicLinkInfo.AnalyticsInfo.ItunesConnectAnalytics.Mt)
 
-	shortPath := params.Suffix.Option == "SHORT"
-	response, err := s.createOrGetShortLink(ctx, host, queryParams, shortPath)
+	unrecognized := params.DynamicLinkInfo.UnrecognizedParams
+	if s.unrecognizedParamPolicy(params.UnrecognizedParamPolicy) == models.UnrecognizedParamsPassThrough {
+		for key, values := range unrecognized {
+			for _, value := range values {
+				queryParams.Add(key, value)
+			}
+		}
+	} else {
+		keys := slices.Sorted(maps.Keys(unrecognized))
+		for _, key := range keys {
+			warnings = append(warnings, models.Warning{
+				WarningCode:    "UNRECOGNIZED_PARAM",
+				WarningMessage: fmt.Sprintf("Param '%s' is not recognized and was dropped.", key),
+			})
+		}
+	}
+
+	option := params.Suffix.Option
+	if option == "" {
+		option = settings.DefaultSuffixOption
+	}
+
+	var response *models.ShortLinkResponse
+	switch option {
+	case models.SuffixOptionCustom:
+		response, err = s.createCustomLink(ctx, host, prefix, settings, params.Suffix.CustomPath, queryParams)
+	case models.SuffixOptionShort:
+		response, err = s.createOrGetShortLink(ctx, host, prefix, settings, queryParams, true)
+	case models.SuffixOptionUnguessable:
+		response, err = s.createOrGetShortLink(ctx, host, prefix, settings, queryParams, false)
+	default:
+		return nil, fmt.Errorf("%w: %q", apperrors.ErrInvalidSuffixOption, option)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -196,6 +306,52 @@ func (s *linkService) CreateDynamicLink(ctx context.Context, params models.Creat
 	return response, nil
 }
 
+func iosParameterWarnings(ios models.IosParameters) []models.Warning {
+	warnings := []models.Warning{}
+
+	if ios.IosBundleId != "" && !utils.IsBundleID(ios.IosBundleId) {
+		warnings = append(warnings, models.Warning{
+			WarningCode:    "MALFORMED_PARAM",
+			WarningMessage: "Param 'ibi' is not a valid bundle ID",
+		})
+	}
+	if ios.IosIpadBundleId != "" && !utils.IsBundleID(ios.IosIpadBundleId) {
+		warnings = append(warnings, models.Warning{
+			WarningCode:    "MALFORMED_PARAM",
+			WarningMessage: "Param 'ipbi' is not a valid bundle ID",
+		})
+	}
+	if ios.IosCustomScheme != "" && !utils.IsURLScheme(ios.IosCustomScheme) {
+		warnings = append(warnings, models.Warning{
+			WarningCode:    "MALFORMED_PARAM",
+			WarningMessage: "Param 'ius' is not a valid URL scheme",
+		})
+	}
+	if ios.IosMinimumVersion != "" && !utils.IsVersionString(ios.IosMinimumVersion) {
+		warnings = append(warnings, models.Warning{
+			WarningCode:    "MALFORMED_PARAM",
+			WarningMessage: "Param 'imv' is not a valid version string",
+		})
+	}
+
+	if ios.IosBundleId == "" {
+		if ios.IosCustomScheme != "" {
+			warnings = append(warnings, models.Warning{
+				WarningCode:    "UNRECOGNIZED_PARAM",
+				WarningMessage: "Param 'ius' is not needed, since 'ibi' is not specified.",
+			})
+		}
+		if ios.IosMinimumVersion != "" {
+			warnings = append(warnings, models.Warning{
+				WarningCode:    "UNRECOGNIZED_PARAM",
+				WarningMessage: "Param 'imv' is not needed, since 'ibi' is not specified.",
+			})
+		}
+	}
+
+	return warnings
+}
+
 func (s *linkService) ParseLongDynamicLink(longDynamicLink string) (models.CreateDynamicLinkRequest, error) {
 	var req models.CreateDynamicLinkRequest
 
@@ -232,6 +388,18 @@ func (s *linkService) ParseLongDynamicLink(longDynamicLink string) (models.Creat
 		req.DynamicLinkInfo.AndroidParameters.AndroidMinPackageVersionCode = apv
 	}
 
+	if ibi := params.Get("ibi"); ibi != "" {
+		req.DynamicLinkInfo.IosParameters.IosBundleId = ibi
+	}
+	if ius := params.Get("ius"); ius != "" {
+		req.DynamicLinkInfo.IosParameters.IosCustomScheme = ius
+	}
+	if ipbi := params.Get("ipbi"); ipbi != "" {
+		req.DynamicLinkInfo.IosParameters.IosIpadBundleId = ipbi
+	}
+	if imv := params.Get("imv"); imv != "" {
+		req.DynamicLinkInfo.IosParameters.IosMinimumVersion = imv
+	}
 	if isi := params.Get("isi"); isi != "" {
 		req.DynamicLinkInfo.IosParameters.IosAppStoreId = isi
 	}
@@ -284,9 +452,26 @@ func (s *linkService) ParseLongDynamicLink(longDynamicLink string) (models.Creat
 		req.DynamicLinkInfo.SocialMetaTagInfo.SocialImageLink = socialImageLink
 	}
 
+	if efr := params.Get("efr"); efr == "1" {
+		req.DynamicLinkInfo.NavigationInfo.EnableForcedRedirect = true
+	}
+
+	for key, values := range params {
+		if slices.Contains(knownLinkParams, key) {
+			continue
+		}
+		if req.DynamicLinkInfo.UnrecognizedParams == nil {
+			req.DynamicLinkInfo.UnrecognizedParams = url.Values{}
+		}
+		req.DynamicLinkInfo.UnrecognizedParams[key] = values
+	}
+
 	if pathOption := params.Get("path"); pathOption != "" {
 		req.Suffix.Option = pathOption
 	}
+	if customPath := params.Get("customPath"); customPath != "" {
+		req.Suffix.CustomPath = customPath
+	}
 
 	log.Debug().
 		Str("req", fmt.Sprintf("%+v", req)).
@@ -297,14 +482,15 @@ func (s *linkService) ParseLongDynamicLink(longDynamicLink string) (models.Creat
 
 func (s *linkService) createOrGetShortLink(
 	ctx context.Context,
-	host string,
+	host, prefix string,
+	settings models.DomainSettings,
 	queryParams url.Values,
 	shortPath bool,
 ) (*models.ShortLinkResponse, error) {
 	rawQS := queryParams.Encode()
 	if shortPath {
 		if path, err := s.findExistingShortLink(ctx, host, rawQS); err == nil {
-			full := fmt.Sprintf("%s://%s/%s", s.cfg.URLScheme, host, path)
+			full := shortLinkURL(settings, host, prefix, path)
 			log.Debug().
 				Str("path", path).
 				Str("query_params", rawQS).
@@ -319,17 +505,24 @@ func (s *linkService) createOrGetShortLink(
 		}
 	}
 
-	length := s.cfg.ShortPathLength
+	length := settings.ShortPathLength
 	if !shortPath {
-		length = s.cfg.UnguessablePathLength
+		length = settings.UnguessablePathLength
 	}
-	path := utils.GenerateDynamicLinkPath(length)
 
-	if err := s.createShortLink(ctx, host, path, rawQS, !shortPath); err != nil {
+	store := func(path string) (string, error) {
+		if shortPath {
+			return s.repo.CreateOrGetShortLink(ctx, host, path, rawQS)
+		}
+		return path, s.createShortLink(ctx, host, path, rawQS, true)
+	}
+
+	path, err := s.storeWithNewPath(host, length, settings.PathAlphabet, store)
+	if err != nil {
 		return nil, fmt.Errorf("failed to store link: %w", err)
 	}
 
-	full := fmt.Sprintf("%s://%s/%s", s.cfg.URLScheme, host, path)
+	full := shortLinkURL(settings, host, prefix, path)
 	log.Debug().
 		Str("path", path).
 		Str("query_params", rawQS).
@@ -338,6 +531,181 @@ func (s *linkService) createOrGetShortLink(
 	return &models.ShortLinkResponse{ShortLink: full, Warnings: []models.Warning{}}, nil
 }
 
+type fallbackLink struct {
+	param string
+	link  string
+}
+
+// fallbackLinks lists the set fallback URLs a click can be redirected to, so
+// they get the same scheme and allow list checks as link.
+func fallbackLinks(info models.DynamicLinkInfo) []fallbackLink {
+	candidates := []fallbackLink{
+		{"afl", info.AndroidParameters.AndroidFallbackLink},
+		{"ifl", info.IosParameters.IosFallbackLink},
+		{"ipfl", info.IosParameters.IosIpadFallbackLink},
+		{"ofl", info.OtherPlatformParameters.FallbackURL},
+	}
+
+	links := []fallbackLink{}
+	for _, c := range candidates {
+		if c.link != "" {
+			links = append(links, c)
+		}
+	}
+	return links
+}
+
+// fallbackAllowList falls back to the host's link allow list when no separate
+// list is configured for fallback URLs.
+func (s *linkService) fallbackAllowList(settings models.DomainSettings) []string {
+	if len(s.cfg.FallbackDomainAllowList) > 0 {
+		return s.cfg.FallbackDomainAllowList
+	}
+	return settings.DomainAllowList
+}
+
+// applyDefaultAppParameters fills the app identifiers the request left out
+// and returns the long link keys it filled. The iOS App Store ID is only
+// defaulted alongside the default bundle ID, never for a different app.
+func applyDefaultAppParameters(info *models.DynamicLinkInfo, defaults models.DefaultAppParameters) []string {
+	defaulted := []string{}
+
+	if info.AndroidParameters.AndroidPackageName == "" && defaults.AndroidPackageName != "" {
+		info.AndroidParameters.AndroidPackageName = defaults.AndroidPackageName
+		defaulted = append(defaulted, "apn")
+	}
+
+	ios := &info.IosParameters
+	if ios.IosBundleId == "" && defaults.IosBundleId != "" {
+		ios.IosBundleId = defaults.IosBundleId
+		defaulted = append(defaulted, "ibi")
+	}
+	if ios.IosAppStoreId == "" && defaults.IosAppStoreId != "" &&
+		(defaults.IosBundleId == "" || ios.IosBundleId == defaults.IosBundleId) {
+		ios.IosAppStoreId = defaults.IosAppStoreId
+		defaulted = append(defaulted, "isi")
+	}
+
+	return defaulted
+}
+
+// LinkDomain is the domain the link is minted on, either the Firebase style
+// domainUriPrefix or the host, both of which may carry a path prefix.
+func LinkDomain(info models.DynamicLinkInfo) string {
+	if info.DomainUriPrefix != "" {
+		return info.DomainUriPrefix
+	}
+	return info.Host
+}
+
+// shortLinkURL builds the public short link. The prefix is only part of the
+// URL; links are stored under their last path segment.
+func shortLinkURL(settings models.DomainSettings, host, prefix, path string) string {
+	if prefix != "" {
+		path = prefix + "/" + path
+	}
+	return fmt.Sprintf("%s://%s/%s", settings.URLScheme, host, path)
+}
+
+func (s *linkService) unrecognizedParamPolicy(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if s.cfg.UnrecognizedParamPolicy == models.UnrecognizedParamsPassThrough {
+		return models.UnrecognizedParamsPassThrough
+	}
+	return models.UnrecognizedParamsWarn
+}
+
+func (s *linkService) createCustomLink(
+	ctx context.Context,
+	host, prefix string,
+	settings models.DomainSettings,
+	path string,
+	queryParams url.Values,
+) (*models.ShortLinkResponse, error) {
+	if err := s.validateCustomPath(path); err != nil {
+		return nil, err
+	}
+
+	rawQS := queryParams.Encode()
+	err := s.repo.CreateCustomLink(ctx, host, path, rawQS)
+	if errors.Is(err, apperrors.ErrPathConflict) {
+		log.Debug().
+			Str("host", host).
+			Str("path", path).
+			Msg("Custom path already taken")
+		return nil, apperrors.ErrPathAlreadyExists
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to store link: %w", err)
+	}
+
+	full := shortLinkURL(settings, host, prefix, path)
+	log.Debug().
+		Str("path", path).
+		Str("query_params", rawQS).
+		Msg("Custom link stored in database")
+
+	return &models.ShortLinkResponse{ShortLink: full, Warnings: []models.Warning{}}, nil
+}
+
+func (s *linkService) validateCustomPath(path string) error {
+	if err := utils.ValidateCustomPath(path, s.cfg.CustomPathMaxLength); err != nil {
+		return fmt.Errorf("%w: %v", apperrors.ErrInvalidCustomPath, err)
+	}
+	if slices.Contains(reservedPaths, strings.ToLower(path)) {
+		return fmt.Errorf("%w: %q is reserved", apperrors.ErrInvalidCustomPath, path)
+	}
+	return nil
+}
+
+// storeWithNewPath retries on (host, path) collisions and grows the path by
+// one character each time the attempts for the current length run out. store
+// returns the path the link ended up under, which for deduplicated SHORT links
+// may differ from the generated one.
+func (s *linkService) storeWithNewPath(
+	host string,
+	length int,
+	alphabet string,
+	store func(path string) (string, error),
+) (string, error) {
+	attempts := max(s.cfg.PathGenerationAttempts, 1)
+	collisions := 0
+
+	for growth := 0; growth <= max(s.cfg.MaxPathLengthGrowth, 0); growth++ {
+		for range attempts {
+			path := utils.GenerateDynamicLinkPathFromAlphabet(length+growth, alphabet)
+			stored, err := store(path)
+			if err == nil {
+				if collisions > 0 {
+					log.Warn().
+						Str("host", host).
+						Int("collisions", collisions).
+						Int("path_length", length+growth).
+						Msg("Stored link after path collisions")
+				}
+				return stored, nil
+			}
+			if !errors.Is(err, apperrors.ErrPathConflict) {
+				return "", err
+			}
+
+			collisions++
+			log.Debug().
+				Str("host", host).
+				Str("path", path).
+				Int("collisions", collisions).
+				Msg("Generated path already exists, retrying")
+		}
+	}
+
+	log.Error().
+		Str("host", host).
+		Int("collisions", collisions).
+		Msg("Exhausted path generation attempts")
+	return "", fmt.Errorf("no free path after %d collisions: %w", collisions, apperrors.ErrPathConflict)
+}
+
 func (s *linkService) findExistingShortLink(
 	ctx context.Context,
 	host, rawQS string,
@@ -354,19 +722,75 @@ func (s *linkService) createShortLink(
 }
 
 func (s *linkService) ResolveShortPath(ctx context.Context, rawURL string) (*models.LongLinkResponse, error) {
+	host, prefix, path, err := parseShortLink(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := s.domains.GetDomain(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := domainSettings(s.cfg, domain)
+	if prefix != "" && !slices.Contains(settings.PathPrefixes, prefix) {
+		return nil, fmt.Errorf("%w: %s/%s", apperrors.ErrPathPrefixNotRegistered, host, prefix)
+	}
+
+	return s.getLongLinkFromHostAndPath(ctx, host, path, settings)
+}
+
+// registeredDomain maps host, or one of its preview variants, onto the
+// registered short link domain it belongs to.
+func (s *linkService) registeredDomain(ctx context.Context, host string) (*models.Domain, error) {
+	return s.domains.GetDomain(ctx, removePreviewFromHost(host))
+}
+
+func (s *linkService) ResolveDynamicLink(ctx context.Context, rawURL string) (*models.DynamicLinkInfo, error) {
+	longLink, err := s.ResolveShortPath(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := s.ParseLongDynamicLink(longLink.LongLink)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse stored link: %w", err)
+	}
+
+	return &req.DynamicLinkInfo, nil
+}
+
+// ShortLinkKey returns the (host, path) a short link is stored under.
+func ShortLinkKey(rawURL string) (string, string, error) {
+	host, _, path, err := parseShortLink(rawURL)
+	return host, path, err
+}
+
+// parseShortLink splits a short link into its host, path prefix and the path
+// the link is stored under, which is always the last segment.
+func parseShortLink(rawURL string) (string, string, string, error) {
 	u, err := url.Parse(rawURL)
 	if err != nil {
-		return nil, apperrors.ErrInvalidRequestedLink
+		return "", "", "", apperrors.ErrInvalidRequestedLink
 	}
 
-	normalizedHost := removePreviewFromHost(u.Host)
+	host, err := utils.NormalizeHost(u.Hostname())
+	if err != nil {
+		return "", "", "", apperrors.ErrInvalidRequestedLink
+	}
+	normalizedHost := removePreviewFromHost(host)
 
 	pathParts := strings.Split(strings.Trim(u.Path, "/"), "/")
-	if len(pathParts) != 1 {
-		return nil, fmt.Errorf("unexpected path format: %w", apperrors.ErrInvalidPathFormat)
+	if slices.Contains(pathParts, "") {
+		return "", "", "", fmt.Errorf("unexpected path format: %w", apperrors.ErrInvalidPathFormat)
 	}
 
-	return s.getLongLinkFromHostAndPath(ctx, normalizedHost, pathParts[0])
+	last := len(pathParts) - 1
+	return normalizedHost, strings.Join(pathParts[:last], "/"), pathParts[last], nil
+}
+
+func IsPreviewHost(host string) bool {
+	return removePreviewFromHost(host) != host
 }
 
 func removePreviewFromHost(host string) string {
@@ -391,6 +815,15 @@ func (s *linkService) PrepareDynamicLinkRequest(input map[string]any) (models.Cr
 			return models.CreateDynamicLinkRequest{}, err
 		}
 		req = parsedReq
+		if u, err := url.Parse(longLink); err == nil && strings.Trim(u.Path, "/") != "" {
+			req.DynamicLinkInfo.DomainUriPrefix = u.Scheme + "://" + u.Host + u.Path
+		}
+		if policy, ok := input["unrecognizedParamPolicy"].(string); ok {
+			req.UnrecognizedParamPolicy = policy
+		}
+		if skip, ok := input["skipDefaultAppParameters"].(bool); ok {
+			req.SkipDefaultAppParameters = skip
+		}
 	} else {
 		reqBytes, err := json.Marshal(input)
 		if err != nil {
@@ -401,7 +834,7 @@ func (s *linkService) PrepareDynamicLinkRequest(input map[string]any) (models.Cr
 		}
 	}
 
-	if req.DynamicLinkInfo.Host == "" {
+	if LinkDomain(req.DynamicLinkInfo) == "" {
 		return models.CreateDynamicLinkRequest{}, apperrors.ErrMissingHost
 	}
 	if req.DynamicLinkInfo.Link == "" {
@@ -410,6 +843,41 @@ func (s *linkService) PrepareDynamicLinkRequest(input map[string]any) (models.Cr
 	if err := utils.ValidateURLScheme(req.DynamicLinkInfo.Link); err != nil {
 		return models.CreateDynamicLinkRequest{}, err
 	}
+	for _, fallback := range fallbackLinks(req.DynamicLinkInfo) {
+		if err := utils.ValidateURLScheme(fallback.link); err != nil {
+			return models.CreateDynamicLinkRequest{}, &apperrors.ParamError{
+				Param: fallback.param,
+				Err:   fmt.Errorf("%w: %v", apperrors.ErrInvalidFallbackLink, err),
+			}
+		}
+	}
+
+	if policy := req.UnrecognizedParamPolicy; policy != "" && !models.IsValidUnrecognizedParamPolicy(policy) {
+		return models.CreateDynamicLinkRequest{}, fmt.Errorf(
+			"%w: %q, must be %s or %s",
+			apperrors.ErrInvalidParamPolicy,
+			req.UnrecognizedParamPolicy,
+			models.UnrecognizedParamsWarn,
+			models.UnrecognizedParamsPassThrough,
+		)
+	}
+
+	if option := req.Suffix.Option; option != "" && !models.IsValidSuffixOption(option) {
+		return models.CreateDynamicLinkRequest{}, fmt.Errorf(
+			"%w: %q, must be one of %s, %s or %s",
+			apperrors.ErrInvalidSuffixOption,
+			option,
+			models.SuffixOptionShort,
+			models.SuffixOptionUnguessable,
+			models.SuffixOptionCustom,
+		)
+	}
+
+	if req.Suffix.Option == models.SuffixOptionCustom {
+		if err := s.validateCustomPath(req.Suffix.CustomPath); err != nil {
+			return models.CreateDynamicLinkRequest{}, err
+		}
+	}
 
 	return req, nil
 }
@@ -1,15 +1,26 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 
+	"dynamic-links-generator/api/apperrors"
 	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/config"
+	"dynamic-links-generator/utils"
 
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
 )
 
+// Hosts registered in the fake domain registry used by the link service tests.
+var testDomains = []string{"example.com", "example.page.link", "short.page.link", "xn--mnchen-3ya.example"}
+
 func TestMain(m *testing.M) {
 	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
@@ -32,6 +43,10 @@ func TestParseLongDynamicLink(t *testing.T) {
 				"&afl=https://android-fallback.com" +
 				"&amv=123" +
 				"&isi=123456789" +
+				"&ibi=com.ios.app" +
+				"&ius=iosapp" +
+				"&ipbi=com.ios.ipadapp" +
+				"&imv=2.1.0" +
 				"&ifl=https://ios-fallback.com" +
 				"&ipfl=https://ipad-fallback.com" +
 				"&ofl=https://other-platform-fallback.com" +
@@ -47,6 +62,7 @@ func TestParseLongDynamicLink(t *testing.T) {
 				"&st=social title" +
 				"&sd=social description" +
 				"&si=https://social-image.com" +
+				"&efr=1" +
 				"&path=SHORT",
 			want: models.CreateDynamicLinkRequest{
 				DynamicLinkInfo: models.DynamicLinkInfo{
@@ -59,6 +75,10 @@ func TestParseLongDynamicLink(t *testing.T) {
 					},
 					IosParameters: models.IosParameters{
 						IosAppStoreId:       "123456789",
+						IosBundleId:         "com.ios.app",
+						IosCustomScheme:     "iosapp",
+						IosIpadBundleId:     "com.ios.ipadapp",
+						IosMinimumVersion:   "2.1.0",
 						IosFallbackLink:     "https://ios-fallback.com",
 						IosIpadFallbackLink: "https://ipad-fallback.com",
 					},
@@ -85,6 +105,9 @@ func TestParseLongDynamicLink(t *testing.T) {
 						SocialDescription: "social description",
 						SocialImageLink:   "https://social-image.com",
 					},
+					NavigationInfo: models.NavigationInfo{
+						EnableForcedRedirect: true,
+					},
 				},
 				Suffix: models.Suffix{
 					Option: "SHORT",
@@ -168,3 +191,969 @@ func TestRemovePreviewFromHost(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectTarget(t *testing.T) {
+	full := models.DynamicLinkInfo{
+		Link: "https://target.com",
+		AndroidParameters: models.AndroidParameters{
+			AndroidPackageName:  "com.android.app",
+			AndroidFallbackLink: "https://android-fallback.com",
+		},
+		IosParameters: models.IosParameters{
+			IosFallbackLink:     "https://ios-fallback.com",
+			IosIpadFallbackLink: "https://ipad-fallback.com",
+			IosAppStoreId:       "123456789",
+		},
+		OtherPlatformParameters: models.OtherPlatformParameters{
+			FallbackURL: "https://other-platform-fallback.com",
+		},
+	}
+	storesOnly := models.DynamicLinkInfo{
+		Link: "https://target.com",
+		AndroidParameters: models.AndroidParameters{
+			AndroidPackageName: "com.android.app",
+		},
+		IosParameters: models.IosParameters{
+			IosAppStoreId: "123456789",
+		},
+	}
+	linkOnly := models.DynamicLinkInfo{Link: "https://target.com"}
+
+	tests := []struct {
+		name     string
+		info     models.DynamicLinkInfo
+		platform utils.Platform
+		want     string
+	}{
+		{"android fallback", full, utils.PlatformAndroid, "https://android-fallback.com"},
+		{"android play store", storesOnly, utils.PlatformAndroid, "https://play.google.com/store/apps/details?id=com.android.app"},
+		{"android link", linkOnly, utils.PlatformAndroid, "https://target.com"},
+		{"ios fallback", full, utils.PlatformIOS, "https://ios-fallback.com"},
+		{"ios app store", storesOnly, utils.PlatformIOS, "https://apps.apple.com/app/id123456789"},
+		{"ipad fallback", full, utils.PlatformIPad, "https://ipad-fallback.com"},
+		{"ipad app store", storesOnly, utils.PlatformIPad, "https://apps.apple.com/app/id123456789"},
+		{"desktop fallback", full, utils.PlatformDesktop, "https://other-platform-fallback.com"},
+		{"desktop link", storesOnly, utils.PlatformDesktop, "https://target.com"},
+		{"other platform", full, utils.PlatformOther, "https://target.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RedirectTarget(tt.info, tt.platform))
+		})
+	}
+}
+
+type fakeLink struct {
+	rawQS       string
+	unguessable bool
+	custom      bool
+}
+
+type fakeLinkRepository struct {
+	mu        sync.Mutex
+	links     map[string]fakeLink
+	conflicts int
+	attempted []string
+}
+
+func newFakeLinkRepository(rawQSByKey map[string]string) *fakeLinkRepository {
+	repo := &fakeLinkRepository{links: map[string]fakeLink{}}
+	for key, rawQS := range rawQSByKey {
+		repo.links[key] = fakeLink{rawQS: rawQS}
+	}
+	return repo
+}
+
+func (f *fakeLinkRepository) GetQueryParamsByHostAndPath(_ context.Context, host, path string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	link, ok := f.links[host+"/"+path]
+	if !ok {
+		return "", apperrors.ErrLinkNotFound
+	}
+	return link.rawQS, nil
+}
+
+func (f *fakeLinkRepository) FindExistingShortLink(_ context.Context, host, rawQS string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.findLocked(host, rawQS)
+}
+
+func (f *fakeLinkRepository) findLocked(host, rawQS string) (string, error) {
+	for key, link := range f.links {
+		path, ok := strings.CutPrefix(key, host+"/")
+		if ok && link.rawQS == rawQS && !link.unguessable && !link.custom {
+			return path, nil
+		}
+	}
+	return "", sql.ErrNoRows
+}
+
+func (f *fakeLinkRepository) CreateShortLink(_ context.Context, host, path, rawQS string, unguessable bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.insertLocked(host, path, rawQS, unguessable)
+}
+
+func (f *fakeLinkRepository) CreateOrGetShortLink(_ context.Context, host, path, rawQS string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if existing, err := f.findLocked(host, rawQS); err == nil {
+		return existing, nil
+	}
+	if err := f.insertLocked(host, path, rawQS, false); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (f *fakeLinkRepository) CreateCustomLink(_ context.Context, host, path, rawQS string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.links[host+"/"+path]; ok {
+		return apperrors.ErrPathConflict
+	}
+	f.links[host+"/"+path] = fakeLink{rawQS: rawQS, custom: true}
+	return nil
+}
+
+func (f *fakeLinkRepository) insertLocked(host, path, rawQS string, unguessable bool) error {
+	f.attempted = append(f.attempted, path)
+	if f.conflicts > 0 {
+		f.conflicts--
+		return apperrors.ErrPathConflict
+	}
+	if _, ok := f.links[host+"/"+path]; ok {
+		return apperrors.ErrPathConflict
+	}
+	f.links[host+"/"+path] = fakeLink{rawQS: rawQS, unguessable: unguessable}
+	return nil
+}
+
+func TestResolveDynamicLink(t *testing.T) {
+	repo := newFakeLinkRepository(map[string]string{
+		"example.com/abc123": "link=https%3A%2F%2Ftarget.com&apn=com.android.app&isi=123456789",
+	})
+	svc := NewLinkService(repo, newFakeDomainRepository(testDomains...), &config.Config{URLScheme: "https"})
+
+	tests := []struct {
+		name    string
+		rawURL  string
+		wantErr error
+	}{
+		{"plain host", "https://example.com/abc123", nil},
+		{"host with port", "https://example.com:9010/abc123", nil},
+		{"preview host", "https://preview.example.com/abc123", nil},
+		{"unknown path", "https://example.com/missing", apperrors.ErrLinkNotFound},
+		{"nested path", "https://example.com/a/b", apperrors.ErrPathPrefixNotRegistered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := svc.ResolveDynamicLink(context.Background(), tt.rawURL)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, "example.com", info.Host)
+			assert.Equal(t, "https://target.com", info.Link)
+			assert.Equal(t, "com.android.app", info.AndroidParameters.AndroidPackageName)
+			assert.Equal(t, "123456789", info.IosParameters.IosAppStoreId)
+		})
+	}
+}
+
+func TestCreateOrGetShortLink_PathCollisions(t *testing.T) {
+	cfg := &config.Config{
+		URLScheme:              "https",
+		ShortPathLength:        6,
+		UnguessablePathLength:  10,
+		PathGenerationAttempts: 2,
+		MaxPathLengthGrowth:    1,
+	}
+
+	tests := []struct {
+		name        string
+		conflicts   int
+		wantLengths []int
+		wantErr     bool
+	}{
+		{"no collision", 0, []int{6}, false},
+		{"retry at same length", 1, []int{6, 6}, false},
+		{"grow after attempts exhausted", 2, []int{6, 6, 7}, false},
+		{"give up after max growth", 4, []int{6, 6, 7, 7}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeLinkRepository(nil)
+			repo.conflicts = tt.conflicts
+			svc := NewLinkService(repo, newFakeDomainRepository(testDomains...), cfg)
+
+			resp, err := svc.createOrGetShortLink(context.Background(), "example.com", "", configSettings(cfg, "example.com"), url.Values{"link": {"https://target.com"}}, true)
+
+			var lengths []int
+			for _, path := range repo.attempted {
+				lengths = append(lengths, len(path))
+			}
+			assert.Equal(t, tt.wantLengths, lengths)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrPathConflict)
+				return
+			}
+			assert.NoError(t, err)
+			last := repo.attempted[len(repo.attempted)-1]
+			assert.Equal(t, "https://example.com/"+last, resp.ShortLink)
+		})
+	}
+}
+
+func TestCreateDynamicLink_CustomPath(t *testing.T) {
+	repo := newFakeLinkRepository(nil)
+	svc := NewLinkService(repo, newFakeDomainRepository(testDomains...), &config.Config{
+		URLScheme:             "https",
+		ShortPathLength:       6,
+		UnguessablePathLength: 10,
+		CustomPathMaxLength:   32,
+		DomainAllowList:       []string{"target.com"},
+	})
+	info := models.DynamicLinkInfo{
+		Host: "example.page.link",
+		Link: "https://target.com/sale",
+	}
+
+	resp, err := svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{
+		DynamicLinkInfo: info,
+		Suffix:          models.Suffix{Option: "CUSTOM", CustomPath: "spring-sale"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.page.link/spring-sale", resp.ShortLink)
+
+	_, err = svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{
+		DynamicLinkInfo: models.DynamicLinkInfo{Host: "example.page.link", Link: "https://target.com/other"},
+		Suffix:          models.Suffix{Option: "CUSTOM", CustomPath: "spring-sale"},
+	})
+	assert.ErrorIs(t, err, apperrors.ErrPathAlreadyExists)
+
+	short, err := svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{
+		DynamicLinkInfo: info,
+		Suffix:          models.Suffix{Option: "SHORT"},
+	})
+	assert.NoError(t, err)
+	assert.NotEqual(t, resp.ShortLink, short.ShortLink, "SHORT dedup must not reuse a custom path")
+}
+
+func TestPrepareDynamicLinkRequest_CustomPath(t *testing.T) {
+	svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{CustomPathMaxLength: 16})
+
+	tests := []struct {
+		name       string
+		customPath string
+		wantErr    bool
+	}{
+		{"valid", "spring-sale", false},
+		{"missing", "", true},
+		{"too long", "this-path-is-far-too-long", true},
+		{"invalid character", "spring/sale", true},
+		{"reserved", "v1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.PrepareDynamicLinkRequest(map[string]any{
+				"dynamicLinkInfo": map[string]any{
+					"host": "example.page.link",
+					"link": "https://target.com",
+				},
+				"suffix": map[string]any{
+					"option":     "CUSTOM",
+					"customPath": tt.customPath,
+				},
+			})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrInvalidCustomPath)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPrepareDynamicLinkRequest_SuffixOption(t *testing.T) {
+	svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{CustomPathMaxLength: 16})
+
+	tests := []struct {
+		name    string
+		option  string
+		wantErr bool
+	}{
+		{"omitted", "", false},
+		{"short", "SHORT", false},
+		{"unguessable", "UNGUESSABLE", false},
+		{"typo", "SHROT", true},
+		{"lower case", "short", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.PrepareDynamicLinkRequest(map[string]any{
+				"longDynamicLink": "https://example.page.link/?link=https://target.com&path=" + tt.option,
+			})
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrInvalidSuffixOption)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCreateDynamicLink_DefaultSuffixOption(t *testing.T) {
+	svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{
+		URLScheme:             "https",
+		ShortPathLength:       6,
+		UnguessablePathLength: 10,
+		DefaultSuffixOption:   "UNGUESSABLE",
+		HostSuffixOptions:     map[string]string{"short.page.link": "SHORT", "example.com": "CUSTOM"},
+		DomainAllowList:       []string{"target.com"},
+	})
+
+	tests := []struct {
+		host       string
+		wantLength int
+	}{
+		{"example.page.link", 10},
+		{"short.page.link", 6},
+		{"SHORT.page.link", 6},
+		// CUSTOM needs a customPath, so it cannot be a default.
+		{"example.com", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			resp, err := svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{
+				DynamicLinkInfo: models.DynamicLinkInfo{Host: tt.host, Link: "https://target.com"},
+			})
+			assert.NoError(t, err)
+
+			u, err := url.Parse(resp.ShortLink)
+			assert.NoError(t, err)
+			assert.Len(t, strings.Trim(u.Path, "/"), tt.wantLength)
+		})
+	}
+}
+
+func TestIosParameterWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		ios  models.IosParameters
+		want []string
+	}{
+		{
+			name: "valid parameters",
+			ios: models.IosParameters{
+				IosBundleId:       "com.example.app",
+				IosCustomScheme:   "exampleapp",
+				IosIpadBundleId:   "com.example.ipad",
+				IosMinimumVersion: "1.2.3",
+			},
+			want: nil,
+		},
+		{
+			name: "malformed parameters",
+			ios: models.IosParameters{
+				IosBundleId:       "com..example",
+				IosCustomScheme:   "1scheme",
+				IosIpadBundleId:   "com.example.ipad!",
+				IosMinimumVersion: "v1.2",
+			},
+			want: []string{
+				"Param 'ibi' is not a valid bundle ID",
+				"Param 'ipbi' is not a valid bundle ID",
+				"Param 'ius' is not a valid URL scheme",
+				"Param 'imv' is not a valid version string",
+			},
+		},
+		{
+			name: "scheme and version without bundle id",
+			ios: models.IosParameters{
+				IosCustomScheme:   "exampleapp",
+				IosMinimumVersion: "1.0",
+			},
+			want: []string{
+				"Param 'ius' is not needed, since 'ibi' is not specified.",
+				"Param 'imv' is not needed, since 'ibi' is not specified.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, w := range iosParameterWarnings(tt.ios) {
+				got = append(got, w.WarningMessage)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestShowsAppPreview(t *testing.T) {
+	app := models.DynamicLinkInfo{
+		Link:              "https://target.com",
+		AndroidParameters: models.AndroidParameters{AndroidPackageName: "com.android.app"},
+		IosParameters:     models.IosParameters{IosBundleId: "com.ios.app"},
+	}
+	forced := app
+	forced.NavigationInfo.EnableForcedRedirect = true
+	linkOnly := models.DynamicLinkInfo{Link: "https://target.com"}
+
+	tests := []struct {
+		name     string
+		info     models.DynamicLinkInfo
+		platform utils.Platform
+		want     bool
+	}{
+		{"android app", app, utils.PlatformAndroid, true},
+		{"ios app", app, utils.PlatformIOS, true},
+		{"ipad app", app, utils.PlatformIPad, true},
+		{"desktop never", app, utils.PlatformDesktop, false},
+		{"forced redirect", forced, utils.PlatformAndroid, false},
+		{"forced redirect ios", forced, utils.PlatformIOS, false},
+		{"no app", linkOnly, utils.PlatformAndroid, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ShowsAppPreview(tt.info, tt.platform))
+		})
+	}
+}
+
+func TestCreateDynamicLink_ForcedRedirectRoundTrip(t *testing.T) {
+	svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{
+		URLScheme:             "https",
+		ShortPathLength:       6,
+		UnguessablePathLength: 10,
+		DomainAllowList:       []string{"target.com"},
+	})
+
+	resp, err := svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{
+		DynamicLinkInfo: models.DynamicLinkInfo{
+			Host:           "example.page.link",
+			Link:           "https://target.com",
+			NavigationInfo: models.NavigationInfo{EnableForcedRedirect: true},
+		},
+	})
+	assert.NoError(t, err)
+
+	info, err := svc.ResolveDynamicLink(context.Background(), resp.ShortLink)
+	assert.NoError(t, err)
+	assert.True(t, info.NavigationInfo.EnableForcedRedirect)
+}
+
+func TestCreateDynamicLink_UnrecognizedParams(t *testing.T) {
+	const longLink = "https://example.page.link/?link=https://target.com&apn=com.android.app&ref=abc&ad_id=42"
+
+	tests := []struct {
+		name         string
+		configPolicy string
+		reqPolicy    string
+		wantQuery    url.Values
+		wantWarnings []string
+	}{
+		{
+			name:         "warn by default",
+			wantQuery:    url.Values{"link": {"https://target.com"}, "apn": {"com.android.app"}},
+			wantWarnings: []string{"Param 'ad_id' is not recognized and was dropped.", "Param 'ref' is not recognized and was dropped."},
+		},
+		{
+			name:         "pass through from config",
+			configPolicy: "PASS_THROUGH",
+			wantQuery:    url.Values{"link": {"https://target.com"}, "apn": {"com.android.app"}, "ref": {"abc"}, "ad_id": {"42"}},
+		},
+		{
+			name:         "request overrides config",
+			configPolicy: "PASS_THROUGH",
+			reqPolicy:    "WARN",
+			wantQuery:    url.Values{"link": {"https://target.com"}, "apn": {"com.android.app"}},
+			wantWarnings: []string{"Param 'ad_id' is not recognized and was dropped.", "Param 'ref' is not recognized and was dropped."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{
+				URLScheme:               "https",
+				ShortPathLength:         6,
+				UnguessablePathLength:   10,
+				DomainAllowList:         []string{"target.com"},
+				UnrecognizedParamPolicy: tt.configPolicy,
+			})
+
+			input := map[string]any{"longDynamicLink": longLink}
+			if tt.reqPolicy != "" {
+				input["unrecognizedParamPolicy"] = tt.reqPolicy
+			}
+			req, err := svc.PrepareDynamicLinkRequest(input)
+			assert.NoError(t, err)
+
+			resp, err := svc.CreateDynamicLink(context.Background(), req)
+			assert.NoError(t, err)
+
+			var warnings []string
+			for _, w := range resp.Warnings {
+				warnings = append(warnings, w.WarningMessage)
+			}
+			assert.Equal(t, tt.wantWarnings, warnings)
+
+			resolved, err := svc.ResolveShortPath(context.Background(), resp.ShortLink)
+			assert.NoError(t, err)
+			u, err := url.Parse(resolved.LongLink)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantQuery, u.Query())
+		})
+	}
+}
+
+func TestPrepareDynamicLinkRequest_InvalidParamPolicy(t *testing.T) {
+	svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{})
+
+	_, err := svc.PrepareDynamicLinkRequest(map[string]any{
+		"longDynamicLink":         "https://example.page.link/?link=https://target.com",
+		"unrecognizedParamPolicy": "KEEP",
+	})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidParamPolicy)
+}
+
+func TestPrepareDynamicLinkRequest_FallbackScheme(t *testing.T) {
+	svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{})
+
+	tests := []struct {
+		name      string
+		longLink  string
+		wantParam string
+	}{
+		{"valid fallbacks", "https://example.page.link/?link=https://target.com&afl=https://target.com/a&ofl=http://target.com/o", ""},
+		{"javascript afl", "https://example.page.link/?link=https://target.com&afl=javascript:alert(1)", "afl"},
+		{"ftp ifl", "https://example.page.link/?link=https://target.com&ifl=ftp://target.com/i", "ifl"},
+		{"custom scheme ipfl", "https://example.page.link/?link=https://target.com&ipfl=myapp://open", "ipfl"},
+		{"data ofl", "https://example.page.link/?link=https://target.com&ofl=data:text/html,hi", "ofl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.PrepareDynamicLinkRequest(map[string]any{"longDynamicLink": tt.longLink})
+			if tt.wantParam == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, apperrors.ErrInvalidFallbackLink)
+			var paramErr *apperrors.ParamError
+			if assert.ErrorAs(t, err, &paramErr) {
+				assert.Equal(t, tt.wantParam, paramErr.Param)
+			}
+		})
+	}
+}
+
+func TestCreateDynamicLink_FallbackAllowList(t *testing.T) {
+	tests := []struct {
+		name          string
+		fallbackAllow []string
+		info          models.DynamicLinkInfo
+		wantParam     string
+	}{
+		{
+			name: "fallbacks on link allow list",
+			info: models.DynamicLinkInfo{
+				AndroidParameters:       models.AndroidParameters{AndroidFallbackLink: "https://target.com/android"},
+				OtherPlatformParameters: models.OtherPlatformParameters{FallbackURL: "https://target.com/desktop"},
+			},
+		},
+		{
+			name:      "afl outside allow list",
+			info:      models.DynamicLinkInfo{AndroidParameters: models.AndroidParameters{AndroidFallbackLink: "https://evil.com"}},
+			wantParam: "afl",
+		},
+		{
+			name:      "ipfl outside allow list",
+			info:      models.DynamicLinkInfo{IosParameters: models.IosParameters{IosIpadFallbackLink: "https://evil.com"}},
+			wantParam: "ipfl",
+		},
+		{
+			name:          "separate fallback allow list",
+			fallbackAllow: []string{"store.target.com"},
+			info:          models.DynamicLinkInfo{IosParameters: models.IosParameters{IosFallbackLink: "https://store.target.com"}},
+		},
+		{
+			name:          "separate fallback allow list replaces link allow list",
+			fallbackAllow: []string{"store.target.com"},
+			info:          models.DynamicLinkInfo{OtherPlatformParameters: models.OtherPlatformParameters{FallbackURL: "https://target.com"}},
+			wantParam:     "ofl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{
+				URLScheme:               "https",
+				ShortPathLength:         6,
+				UnguessablePathLength:   10,
+				DomainAllowList:         []string{"target.com"},
+				FallbackDomainAllowList: tt.fallbackAllow,
+			})
+
+			info := tt.info
+			info.Host = "example.page.link"
+			info.Link = "https://target.com"
+			_, err := svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{DynamicLinkInfo: info})
+			if tt.wantParam == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, apperrors.ErrFallbackNotAllowed)
+			var paramErr *apperrors.ParamError
+			if assert.ErrorAs(t, err, &paramErr) {
+				assert.Equal(t, tt.wantParam, paramErr.Param)
+			}
+		})
+	}
+}
+
+func TestCreateDynamicLink_InternationalizedHosts(t *testing.T) {
+	svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{
+		URLScheme:             "https",
+		ShortPathLength:       6,
+		UnguessablePathLength: 10,
+		DomainAllowList:       []string{"xn--bcher-kva.example"},
+	})
+
+	resp, err := svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{
+		DynamicLinkInfo: models.DynamicLinkInfo{
+			Host: "münchen.example",
+			Link: "https://bücher.example/buch",
+		},
+	})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(resp.ShortLink, "https://xn--mnchen-3ya.example/"))
+
+	unicodeLink := strings.Replace(resp.ShortLink, "xn--mnchen-3ya", "münchen", 1)
+	long, err := svc.ResolveShortPath(context.Background(), unicodeLink)
+	assert.NoError(t, err)
+	assert.Contains(t, long.LongLink, "https://xn--mnchen-3ya.example/")
+
+	_, err = svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{
+		DynamicLinkInfo: models.DynamicLinkInfo{
+			Host: "münchen.example",
+			Link: "https://bücһer.example/buch",
+		},
+	})
+	assert.ErrorIs(t, err, apperrors.ErrMixedScriptLinkHost)
+}
+
+func TestCreateDynamicLink_DomainRegistry(t *testing.T) {
+	svc := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository("example.page.link"), &config.Config{
+		URLScheme:             "https",
+		ShortPathLength:       6,
+		UnguessablePathLength: 10,
+		DomainAllowList:       []string{"target.com"},
+	})
+
+	tests := []struct {
+		name       string
+		host       string
+		wantPrefix string
+		wantErr    error
+	}{
+		{"registered host", "example.page.link", "https://example.page.link/", nil},
+		{"preview variant uses base domain", "preview.example.page.link", "https://example.page.link/", nil},
+		{"unregistered host", "exmaple.page.link", "", apperrors.ErrDomainNotRegistered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{
+				DynamicLinkInfo: models.DynamicLinkInfo{Host: tt.host, Link: "https://target.com"},
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(resp.ShortLink, tt.wantPrefix))
+		})
+	}
+}
+
+func TestResolveShortPath_DomainRegistry(t *testing.T) {
+	repo := newFakeLinkRepository(map[string]string{
+		"example.com/abc123": "link=https%3A%2F%2Ftarget.com",
+		"unowned.com/abc123": "link=https%3A%2F%2Ftarget.com",
+	})
+	svc := NewLinkService(repo, newFakeDomainRepository("example.com"), &config.Config{URLScheme: "https"})
+
+	_, err := svc.ResolveShortPath(context.Background(), "https://acme-preview.com/abc123")
+	assert.ErrorIs(t, err, apperrors.ErrDomainNotRegistered)
+
+	_, err = svc.ResolveShortPath(context.Background(), "https://unowned.com/abc123")
+	assert.ErrorIs(t, err, apperrors.ErrDomainNotRegistered)
+
+	long, err := svc.ResolveShortPath(context.Background(), "https://preview.example.com/abc123")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/abc123?link=https%3A%2F%2Ftarget.com", long.LongLink)
+}
+
+func TestCreateDynamicLink_DomainSettings(t *testing.T) {
+	domains := newFakeDomainRepository("example.page.link", "file.page.link")
+	domains.domains["brand.page.link"] = models.Domain{
+		Host: "brand.page.link",
+		Settings: models.DomainSettings{
+			UnguessablePathLength: 12,
+			PathAlphabet:          "ab",
+			URLScheme:             "http",
+			DomainAllowList:       []string{"brand.com"},
+			DefaultAppParameters:  models.DefaultAppParameters{AndroidPackageName: "com.brand.app"},
+		},
+	}
+	svc := NewLinkService(newFakeLinkRepository(nil), domains, &config.Config{
+		URLScheme:             "https",
+		ShortPathLength:       6,
+		UnguessablePathLength: 10,
+		DomainAllowList:       []string{"target.com"},
+		DomainSettings: map[string]models.DomainSettings{
+			"file.page.link":  {DefaultSuffixOption: "SHORT", ShortPathLength: 4},
+			"brand.page.link": {UnguessablePathLength: 20, URLScheme: "https"},
+		},
+	})
+
+	create := func(host, link string) (*models.ShortLinkResponse, error) {
+		return svc.CreateDynamicLink(context.Background(), models.CreateDynamicLinkRequest{
+			DynamicLinkInfo: models.DynamicLinkInfo{Host: host, Link: link},
+		})
+	}
+
+	resp, err := create("example.page.link", "https://target.com")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^https://example\.page\.link/\w{10}$`, resp.ShortLink)
+
+	resp, err = create("file.page.link", "https://target.com")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^https://file\.page\.link/\w{4}$`, resp.ShortLink)
+
+	_, err = create("brand.page.link", "https://target.com")
+	assert.ErrorIs(t, err, apperrors.ErrDomainLinkNotAllowed)
+
+	resp, err = create("brand.page.link", "https://brand.com")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^http://brand\.page\.link/[ab]{12}$`, resp.ShortLink)
+
+	long, err := svc.ResolveShortPath(context.Background(), resp.ShortLink)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(long.LongLink, "http://brand.page.link/"))
+	u, err := url.Parse(long.LongLink)
+	assert.NoError(t, err)
+	assert.Equal(t, "com.brand.app", u.Query().Get("apn"))
+}
+
+func TestCreateDynamicLink_DefaultAppParameters(t *testing.T) {
+	defaults := models.DefaultAppParameters{
+		AndroidPackageName: "com.brand.android",
+		IosBundleId:        "com.brand.ios",
+		IosAppStoreId:      "123456789",
+	}
+
+	tests := []struct {
+		name         string
+		input        map[string]any
+		wantQuery    url.Values
+		wantDefaults []string
+	}{
+		{
+			name:         "all defaulted",
+			input:        map[string]any{"longDynamicLink": "https://brand.page.link/?link=https://brand.com"},
+			wantQuery:    url.Values{"link": {"https://brand.com"}, "apn": {"com.brand.android"}, "ibi": {"com.brand.ios"}, "isi": {"123456789"}},
+			wantDefaults: []string{"apn", "ibi", "isi"},
+		},
+		{
+			name:         "request values win",
+			input:        map[string]any{"longDynamicLink": "https://brand.page.link/?link=https://brand.com&apn=com.other.android&ibi=com.brand.ios"},
+			wantQuery:    url.Values{"link": {"https://brand.com"}, "apn": {"com.other.android"}, "ibi": {"com.brand.ios"}, "isi": {"123456789"}},
+			wantDefaults: []string{"isi"},
+		},
+		{
+			name:      "isi not defaulted for another bundle",
+			input:     map[string]any{"longDynamicLink": "https://brand.page.link/?link=https://brand.com&apn=com.other.android&ibi=com.other.ios"},
+			wantQuery: url.Values{"link": {"https://brand.com"}, "apn": {"com.other.android"}, "ibi": {"com.other.ios"}},
+		},
+		{
+			name: "opt out",
+			input: map[string]any{
+				"longDynamicLink":          "https://brand.page.link/?link=https://brand.com",
+				"skipDefaultAppParameters": true,
+			},
+			wantQuery: url.Values{"link": {"https://brand.com"}},
+		},
+		{
+			name: "opt out with dynamicLinkInfo",
+			input: map[string]any{
+				"dynamicLinkInfo":          map[string]any{"host": "brand.page.link", "link": "https://brand.com"},
+				"skipDefaultAppParameters": true,
+			},
+			wantQuery: url.Values{"link": {"https://brand.com"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains := newFakeDomainRepository()
+			domains.domains["brand.page.link"] = models.Domain{
+				Host:     "brand.page.link",
+				Settings: models.DomainSettings{DefaultAppParameters: defaults},
+			}
+			svc := NewLinkService(newFakeLinkRepository(nil), domains, &config.Config{
+				URLScheme:             "https",
+				ShortPathLength:       6,
+				UnguessablePathLength: 10,
+				DomainAllowList:       []string{"brand.com"},
+			})
+
+			req, err := svc.PrepareDynamicLinkRequest(tt.input)
+			assert.NoError(t, err)
+			resp, err := svc.CreateDynamicLink(context.Background(), req)
+			assert.NoError(t, err)
+
+			var defaulted []string
+			for _, w := range resp.Warnings {
+				if w.WarningCode == "DEFAULTED_PARAM" {
+					defaulted = append(defaulted, strings.Split(w.WarningMessage, "'")[1])
+				}
+			}
+			assert.Equal(t, tt.wantDefaults, defaulted)
+
+			long, err := svc.ResolveShortPath(context.Background(), resp.ShortLink)
+			assert.NoError(t, err)
+			u, err := url.Parse(long.LongLink)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantQuery, u.Query())
+		})
+	}
+}
+
+func TestCreateDynamicLink_PathPrefix(t *testing.T) {
+	domains := newFakeDomainRepository()
+	domains.domains["example.com"] = models.Domain{
+		Host:     "example.com",
+		Settings: models.DomainSettings{PathPrefixes: []string{"links", "go/app"}},
+	}
+	svc := NewLinkService(newFakeLinkRepository(nil), domains, &config.Config{
+		URLScheme:             "https",
+		ShortPathLength:       6,
+		UnguessablePathLength: 10,
+		CustomPathMaxLength:   16,
+		DomainAllowList:       []string{"target.com"},
+	})
+
+	tests := []struct {
+		name    string
+		input   map[string]any
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "long link under prefix",
+			input: map[string]any{"longDynamicLink": "https://example.com/links/?link=https://target.com"},
+			want:  `^https://example\.com/links/\w{10}$`,
+		},
+		{
+			name: "domainUriPrefix",
+			input: map[string]any{"dynamicLinkInfo": map[string]any{
+				"domainUriPrefix": "https://example.com/go/app",
+				"link":            "https://target.com",
+			}},
+			want: `^https://example\.com/go/app/\w{10}$`,
+		},
+		{
+			name: "host with prefix and custom path",
+			input: map[string]any{
+				"dynamicLinkInfo": map[string]any{"host": "example.com/links", "link": "https://target.com/sale"},
+				"suffix":          map[string]any{"option": "CUSTOM", "customPath": "sale"},
+			},
+			want: `^https://example\.com/links/sale$`,
+		},
+		{
+			name:    "unregistered prefix",
+			input:   map[string]any{"longDynamicLink": "https://example.com/promo/?link=https://target.com"},
+			wantErr: apperrors.ErrPathPrefixNotRegistered,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := svc.PrepareDynamicLinkRequest(tt.input)
+			assert.NoError(t, err)
+
+			resp, err := svc.CreateDynamicLink(context.Background(), req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Regexp(t, tt.want, resp.ShortLink)
+
+			info, err := svc.ResolveDynamicLink(context.Background(), resp.ShortLink)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(info.Link, "https://target.com"))
+		})
+	}
+}
+
+func TestResolveShortPath_PathPrefix(t *testing.T) {
+	repo := newFakeLinkRepository(map[string]string{
+		"example.com/abc123": "link=https%3A%2F%2Ftarget.com",
+	})
+	domains := newFakeDomainRepository()
+	domains.domains["example.com"] = models.Domain{
+		Host:     "example.com",
+		Settings: models.DomainSettings{PathPrefixes: []string{"links"}},
+	}
+	svc := NewLinkService(repo, domains, &config.Config{URLScheme: "https"})
+
+	tests := []struct {
+		name    string
+		rawURL  string
+		wantErr error
+	}{
+		{"no prefix", "https://example.com/abc123", nil},
+		{"registered prefix", "https://example.com/links/abc123", nil},
+		{"registered prefix on preview host", "https://preview.example.com/links/abc123/", nil},
+		{"unregistered prefix", "https://example.com/other/abc123", apperrors.ErrPathPrefixNotRegistered},
+		{"empty segment", "https://example.com/links//abc123", apperrors.ErrInvalidPathFormat},
+		{"unknown path under prefix", "https://example.com/links/missing", apperrors.ErrLinkNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			long, err := svc.ResolveShortPath(context.Background(), tt.rawURL)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "https://example.com/abc123?link=https%3A%2F%2Ftarget.com", long.LongLink)
+		})
+	}
+}
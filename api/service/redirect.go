@@ -0,0 +1,76 @@
+package service
+
+import (
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/utils"
+)
+
+type RedirectDecision struct {
+	Target string
+	Reason string
+}
+
+func RedirectTarget(info models.DynamicLinkInfo, platform utils.Platform) string {
+	return DecideRedirect(info, platform).Target
+}
+
+func DecideRedirect(info models.DynamicLinkInfo, platform utils.Platform) RedirectDecision {
+	android := info.AndroidParameters
+	ios := info.IosParameters
+
+	switch platform {
+	case utils.PlatformAndroid:
+		if android.AndroidFallbackLink != "" {
+			return RedirectDecision{android.AndroidFallbackLink, "Android fallback link (afl) is set"}
+		}
+		if android.AndroidPackageName != "" {
+			return RedirectDecision{utils.PlayStoreURL(android.AndroidPackageName), "No afl; app is not installed, so the Play Store page for apn is opened"}
+		}
+		return RedirectDecision{info.Link, "Neither afl nor apn is set, so the link is opened in the browser"}
+	case utils.PlatformIPad:
+		if ios.IosIpadFallbackLink != "" {
+			return RedirectDecision{ios.IosIpadFallbackLink, "iPad fallback link (ipfl) is set"}
+		}
+		if ios.IosFallbackLink != "" {
+			return RedirectDecision{ios.IosFallbackLink, "No ipfl; iOS fallback link (ifl) is used on iPad"}
+		}
+		if ios.IosAppStoreId != "" {
+			return RedirectDecision{utils.AppStoreURL(ios.IosAppStoreId), "No ipfl or ifl; app is not installed, so the App Store page for isi is opened"}
+		}
+		return RedirectDecision{info.Link, "None of ipfl, ifl or isi is set, so the link is opened in the browser"}
+	case utils.PlatformIOS:
+		if ios.IosFallbackLink != "" {
+			return RedirectDecision{ios.IosFallbackLink, "iOS fallback link (ifl) is set"}
+		}
+		if ios.IosAppStoreId != "" {
+			return RedirectDecision{utils.AppStoreURL(ios.IosAppStoreId), "No ifl; app is not installed, so the App Store page for isi is opened"}
+		}
+		return RedirectDecision{info.Link, "Neither ifl nor isi is set, so the link is opened in the browser"}
+	case utils.PlatformDesktop:
+		if info.OtherPlatformParameters.FallbackURL != "" {
+			return RedirectDecision{info.OtherPlatformParameters.FallbackURL, "Other platform fallback link (ofl) is set"}
+		}
+		return RedirectDecision{info.Link, "No ofl, so the link is opened in the browser"}
+	}
+	return RedirectDecision{info.Link, "Platform not recognized, so the link is opened in the browser"}
+}
+
+// ShowsAppPreview reports whether a click from platform gets the app preview
+// interstitial before being sent on. It is only shown when the link targets
+// an app on that platform and enableForcedRedirect (efr) is not set.
+func ShowsAppPreview(info models.DynamicLinkInfo, platform utils.Platform) bool {
+	if info.NavigationInfo.EnableForcedRedirect {
+		return false
+	}
+
+	ios := info.IosParameters
+	switch platform {
+	case utils.PlatformAndroid:
+		return info.AndroidParameters.AndroidPackageName != ""
+	case utils.PlatformIOS:
+		return ios.IosBundleId != "" || ios.IosAppStoreId != ""
+	case utils.PlatformIPad:
+		return ios.IosIpadBundleId != "" || ios.IosBundleId != "" || ios.IosAppStoreId != ""
+	}
+	return false
+}
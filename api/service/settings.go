@@ -0,0 +1,44 @@
+package service
+
+import (
+	"strings"
+
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/config"
+	"dynamic-links-generator/utils"
+)
+
+// configSettings resolves the settings for host from the process config:
+// entries in DOMAIN_CONFIG_FILE override the global values.
+func configSettings(cfg *config.Config, host string) models.DomainSettings {
+	settings := models.DomainSettings{
+		ShortPathLength:       cfg.ShortPathLength,
+		UnguessablePathLength: cfg.UnguessablePathLength,
+		PathAlphabet:          cfg.PathAlphabet,
+		URLScheme:             cfg.URLScheme,
+		DomainAllowList:       cfg.DomainAllowList,
+		DefaultSuffixOption:   defaultSuffixOption(cfg, host),
+	}
+	if settings.PathAlphabet == "" {
+		settings.PathAlphabet = utils.DefaultPathAlphabet
+	}
+	return settings.Merge(cfg.DomainSettings[host])
+}
+
+// domainSettings layers the settings stored with a registered domain on top
+// of configSettings.
+func domainSettings(cfg *config.Config, domain *models.Domain) models.DomainSettings {
+	return configSettings(cfg, domain.Host).Merge(domain.Settings)
+}
+
+// defaultSuffixOption applies when the request omits suffix.option. As with
+// Firebase, links are UNGUESSABLE unless configured otherwise for the host.
+func defaultSuffixOption(cfg *config.Config, host string) string {
+	if option, ok := cfg.HostSuffixOptions[strings.ToLower(host)]; ok && models.IsValidDefaultSuffixOption(option) {
+		return option
+	}
+	if models.IsValidDefaultSuffixOption(cfg.DefaultSuffixOption) {
+		return cfg.DefaultSuffixOption
+	}
+	return models.SuffixOptionUnguessable
+}
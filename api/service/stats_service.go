@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/api/repository"
+	"dynamic-links-generator/utils"
+)
+
+// Platforms reported by linkStats. Firebase has no iPad bucket, so iPad
+// events are counted as IOS.
+var statsPlatforms = []utils.Platform{
+	utils.PlatformAndroid,
+	utils.PlatformIOS,
+	utils.PlatformDesktop,
+	utils.PlatformOther,
+}
+
+type StatsService interface {
+	LinkStats(ctx context.Context, rawURL string, durationDays int) (*models.LinkStatsResponse, error)
+}
+
+type statsService struct {
+	links  LinkService
+	events repository.EventRepository
+	now    func() time.Time
+}
+
+func NewStatsService(links LinkService, events repository.EventRepository) *statsService {
+	return &statsService{
+		links:  links,
+		events: events,
+		now:    time.Now,
+	}
+}
+
+func (s *statsService) LinkStats(ctx context.Context, rawURL string, durationDays int) (*models.LinkStatsResponse, error) {
+	if durationDays <= 0 {
+		return nil, apperrors.ErrInvalidDurationDays
+	}
+
+	// Resolving validates the domain and prefix and rejects unknown links.
+	if _, err := s.links.ResolveShortPath(ctx, rawURL); err != nil {
+		return nil, err
+	}
+	host, path, err := ShortLinkKey(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	since := s.now().UTC().AddDate(0, 0, -durationDays)
+	counts, err := s.events.CountEvents(ctx, host, path, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count link events: %w", err)
+	}
+
+	return &models.LinkStatsResponse{LinkEventStats: linkEventStats(counts)}, nil
+}
+
+// linkEventStats folds counts into Firebase's platforms and event types, in a
+// stable order. Like Firebase, zero counts are omitted.
+func linkEventStats(counts []models.EventCount) []models.LinkEventStat {
+	totals := map[utils.Platform]map[string]int64{}
+	for _, c := range counts {
+		platform := statsPlatform(utils.Platform(c.Platform))
+		if totals[platform] == nil {
+			totals[platform] = map[string]int64{}
+		}
+		totals[platform][c.EventType] += c.Count
+	}
+
+	stats := []models.LinkEventStat{}
+	for _, platform := range statsPlatforms {
+		for _, event := range models.EventTypes {
+			if n := totals[platform][event]; n > 0 {
+				stats = append(stats, models.LinkEventStat{
+					Platform: string(platform),
+					Count:    n,
+					Event:    event,
+				})
+			}
+		}
+	}
+	return stats
+}
+
+func statsPlatform(platform utils.Platform) utils.Platform {
+	switch platform {
+	case utils.PlatformAndroid, utils.PlatformIOS, utils.PlatformDesktop:
+		return platform
+	case utils.PlatformIPad:
+		return utils.PlatformIOS
+	default:
+		return utils.PlatformOther
+	}
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"dynamic-links-generator/api/apperrors"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkStats(t *testing.T) {
+	links := NewLinkService(newFakeLinkRepository(map[string]string{
+		"example.page.link/abc": "link=https%3A%2F%2Ftarget.com",
+	}), newFakeDomainRepository(testDomains...), &config.Config{URLScheme: "https"})
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	event := func(platform, eventType string, age time.Duration) models.LinkEvent {
+		return models.LinkEvent{Host: "example.page.link", Path: "abc", Platform: platform, EventType: eventType, OccurredAt: now.Add(-age)}
+	}
+	events := &fakeEventRepository{batches: [][]models.LinkEvent{{
+		event("ANDROID", models.EventTypeClick, time.Hour),
+		event("ANDROID", models.EventTypeClick, 2*time.Hour),
+		event("ANDROID", models.EventTypeRedirect, time.Hour),
+		event("IOS", models.EventTypeClick, time.Hour),
+		event("IPAD", models.EventTypeClick, time.Hour),
+		event("DESKTOP", models.EventTypeClick, 10*24*time.Hour),
+		{Host: "example.page.link", Path: "other", Platform: "DESKTOP", EventType: models.EventTypeClick, OccurredAt: now},
+	}}}
+
+	svc := NewStatsService(links, events)
+	svc.now = func() time.Time { return now }
+
+	stats, err := svc.LinkStats(context.Background(), "https://example.page.link/abc", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.LinkEventStat{
+		{Platform: "ANDROID", Count: 2, Event: "CLICK"},
+		{Platform: "ANDROID", Count: 1, Event: "REDIRECT"},
+		{Platform: "IOS", Count: 2, Event: "CLICK"},
+	}, stats.LinkEventStats)
+
+	stats, err = svc.LinkStats(context.Background(), "https://example.page.link/abc", 30)
+	assert.NoError(t, err)
+	assert.Contains(t, stats.LinkEventStats, models.LinkEventStat{Platform: "DESKTOP", Count: 1, Event: "CLICK"})
+}
+
+func TestLinkStats_Errors(t *testing.T) {
+	links := NewLinkService(newFakeLinkRepository(nil), newFakeDomainRepository(testDomains...), &config.Config{URLScheme: "https"})
+	svc := NewStatsService(links, &fakeEventRepository{})
+
+	tests := []struct {
+		name         string
+		rawURL       string
+		durationDays int
+		wantErr      error
+	}{
+		{"zero duration", "https://example.page.link/abc", 0, apperrors.ErrInvalidDurationDays},
+		{"negative duration", "https://example.page.link/abc", -1, apperrors.ErrInvalidDurationDays},
+		{"unknown link", "https://example.page.link/abc", 7, apperrors.ErrLinkNotFound},
+		{"unregistered domain", "https://other.page.link/abc", 7, apperrors.ErrDomainNotRegistered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.LinkStats(context.Background(), tt.rawURL, tt.durationDays)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
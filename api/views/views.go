@@ -0,0 +1,69 @@
+package views
+
+import (
+	"embed"
+	"html/template"
+	"io"
+
+	"dynamic-links-generator/api/models"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+type Destination struct {
+	Platform       string
+	Target         string
+	Reason         string
+	AppPreviewPage bool
+}
+
+type PreviewPage struct {
+	ShortLink     string
+	Info          models.DynamicLinkInfo
+	PlayStoreLink string
+	AppStoreLink  string
+	Destinations  []Destination
+}
+
+type SocialPage struct {
+	ShortLink   string
+	Title       string
+	Description string
+	ImageLink   string
+	RedirectURL string
+}
+
+type AppPreviewPage struct {
+	Title       string
+	Description string
+	ImageLink   string
+	Target      string
+	Link        string
+	ClickID     string // set when the click was fingerprinted for attribution
+}
+
+type DebugPage struct {
+	ShortLink            string
+	Info                 models.DynamicLinkInfo
+	EnableForcedRedirect bool
+	Destinations         []Destination
+}
+
+func RenderPreview(w io.Writer, page PreviewPage) error {
+	return templates.ExecuteTemplate(w, "preview.html", page)
+}
+
+func RenderSocial(w io.Writer, page SocialPage) error {
+	return templates.ExecuteTemplate(w, "social.html", page)
+}
+
+func RenderAppPreview(w io.Writer, page AppPreviewPage) error {
+	return templates.ExecuteTemplate(w, "app_preview.html", page)
+}
+
+func RenderDebug(w io.Writer, page DebugPage) error {
+	return templates.ExecuteTemplate(w, "debug.html", page)
+}
@@ -10,8 +10,12 @@ import (
 	"time"
 
 	"dynamic-links-generator/api"
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/api/repository"
+	"dynamic-links-generator/api/service"
 	"dynamic-links-generator/config"
 	"dynamic-links-generator/db"
+	"dynamic-links-generator/utils"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
@@ -34,13 +38,76 @@ func main() {
 	}
 
 	zerolog.SetGlobalLevel(level)
+
+	if err := utils.ValidatePathAlphabet(cfg.PathAlphabet); err != nil {
+		log.Fatal().Err(err).Msg("Invalid PATH_ALPHABET")
+	}
+	// Existing deployments may run below these minimums, so they only warn
+	// globally; domain overrides are held to them.
+	if err := cfg.PathSettings().ValidatePathLengths(); err != nil {
+		log.Warn().Err(err).Msg("SHORT_PATH_LENGTH, UNGUESSABLE_PATH_LENGTH and PATH_ALPHABET are below the recommended minimums")
+	}
+	if !models.IsValidDefaultSuffixOption(cfg.DefaultSuffixOption) {
+		log.Fatal().Str("option", cfg.DefaultSuffixOption).Msg("Invalid DEFAULT_SUFFIX_OPTION")
+	}
+	// Hosts are looked up normalized, as stored.
+	hostSuffixOptions := make(map[string]string, len(cfg.HostSuffixOptions))
+	for host, option := range cfg.HostSuffixOptions {
+		normalized, err := utils.NormalizeHost(host)
+		if err != nil {
+			log.Fatal().Err(err).Str("host", host).Msg("Invalid HOST_SUFFIX_OPTIONS host")
+		}
+		if !models.IsValidDefaultSuffixOption(option) {
+			log.Fatal().Str("host", host).Str("option", option).Msg("Invalid HOST_SUFFIX_OPTIONS entry")
+		}
+		hostSuffixOptions[normalized] = option
+	}
+	cfg.HostSuffixOptions = hostSuffixOptions
+	if !models.IsValidUnrecognizedParamPolicy(cfg.UnrecognizedParamPolicy) {
+		log.Fatal().Str("policy", cfg.UnrecognizedParamPolicy).Msg("Invalid UNRECOGNIZED_PARAM_POLICY")
+	}
+
+	// Stats are read from the rollups only, so without aggregation they would
+	// stay empty.
+	if cfg.ClickTracking && cfg.RollupIntervalSeconds <= 0 {
+		log.Fatal().Int("seconds", cfg.RollupIntervalSeconds).Msg("ROLLUP_INTERVAL_SECONDS must be positive while CLICK_TRACKING is on")
+	}
+
+	cfg.DomainSettings, err = config.LoadDomainSettings(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid DOMAIN_CONFIG_FILE")
+	}
+
+	log.Info().
+		Int("alphabet_size", len(cfg.PathAlphabet)).
+		Int("short_path_length", cfg.ShortPathLength).
+		Float64("short_path_entropy_bits", utils.PathEntropyBits(cfg.ShortPathLength, cfg.PathAlphabet)).
+		Int("unguessable_path_length", cfg.UnguessablePathLength).
+		Float64("unguessable_path_entropy_bits", utils.PathEntropyBits(cfg.UnguessablePathLength, cfg.PathAlphabet)).
+		Msg("Path keyspace")
+
 	database, err := db.New(cfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
 	defer database.Close()
 
-	router := api.NewRouter(database.DB, cfg)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), database, os.Args[2:]); err != nil {
+			log.Fatal().Err(err).Msg("Migration failed")
+		}
+		return
+	}
+
+	if cfg.RunMigrations {
+		if _, err := database.MigrateUp(context.Background()); err != nil {
+			log.Fatal().Err(err).Msg("Failed to run database migrations")
+		}
+	}
+
+	events := service.NewEventRecorder(repository.NewEventRepository(database.DB), cfg)
+	aggregator := service.NewEventAggregator(repository.NewRollupRepository(database.DB), cfg)
+	router := api.NewRouter(database.DB, cfg, events)
 
 	server := &http.Server{
 		Addr:         fmt.Sprintf("0.0.0.0:%s", cfg.Port),
@@ -65,8 +132,21 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Buffered events are still flushed when requests outlast the timeout;
+	// ones recorded after Close are dropped.
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Server forced to shutdown")
+		log.Error().Err(err).Msg("Server forced to shutdown")
+	}
+
+	// The flush gets its own deadline, long enough for a full batch write.
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancelFlush()
+
+	if err := events.Close(flushCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to flush link events")
+	}
+	if err := aggregator.Close(flushCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to stop link event aggregation")
 	}
 
 	log.Info().Msg("Server exited properly")
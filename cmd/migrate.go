@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"dynamic-links-generator/db"
+)
+
+func runMigrate(ctx context.Context, database *db.DB, args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		applied, err := database.MigrateUp(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := database.MigrateDown(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s)\n", reverted)
+	case "status":
+		status, err := database.MigrationStatus(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Current version: %d\n", status.Current)
+		for _, m := range status.Applied {
+			fmt.Printf("  [applied] %04d_%s\n", m.Version, m.Name)
+		}
+		for _, m := range status.Pending {
+			fmt.Printf("  [pending] %04d_%s\n", m.Version, m.Name)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q (expected up, down [steps] or status)", command)
+	}
+
+	return nil
+}
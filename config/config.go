@@ -5,30 +5,74 @@ import (
 	"strconv"
 	"strings"
 
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/utils"
+
 	_ "github.com/lib/pq"
 )
 
 type Config struct {
-	Port                  string
-	DBDriver              string
-	DBConnectionStr       string
-	ShortPathLength       int
-	UnguessablePathLength int
-	URLScheme             string
-	DomainAllowList       []string
-	LogLevel              string
+	Port                    string
+	DBDriver                string
+	DBConnectionStr         string
+	ShortPathLength         int
+	UnguessablePathLength   int
+	PathAlphabet            string
+	CustomPathMaxLength     int
+	DefaultSuffixOption     string
+	HostSuffixOptions       map[string]string
+	UnrecognizedParamPolicy string
+	PathGenerationAttempts  int
+	MaxPathLengthGrowth     int
+	URLScheme               string
+	DomainAllowList         []string
+	FallbackDomainAllowList []string
+	LogLevel                string
+	AdminAPIKey             string
+	ConversionAPIKey        string
+	StatsAPIKey             string
+	DomainConfigFile        string
+	DomainSettings          map[string]models.DomainSettings
+	ClickTracking           bool
+	EventBufferSize         int
+	EventBatchSize          int
+	EventFlushIntervalMs    int
+	AttributionWindowMins   int
+	RollupIntervalSeconds   int
+	EventRetentionDays      int
+	RunMigrations           bool
 }
 
 func New() *Config {
 	return &Config{
-		Port:                  getEnv("PORT", "9010"),
-		DBDriver:              getEnv("DB_DRIVER", "postgres"),
-		DBConnectionStr:       getEnv("DATABASE_URL", ""),
-		ShortPathLength:       getEnvAsInt("SHORT_PATH_LENGTH", 6),
-		UnguessablePathLength: getEnvAsInt("UNGUESSABLE_PATH_LENGTH", 10),
-		URLScheme:             getEnv("URL_SCHEME", "https"),
-		DomainAllowList:       getEnvAsSlice("DOMAIN_ALLOW_LIST", []string{}),
-		LogLevel:              getEnv("LOG_LEVEL", "info"),
+		Port:                    getEnv("PORT", "9010"),
+		DBDriver:                getEnv("DB_DRIVER", "postgres"),
+		DBConnectionStr:         getEnv("DATABASE_URL", ""),
+		ShortPathLength:         getEnvAsInt("SHORT_PATH_LENGTH", 6),
+		UnguessablePathLength:   getEnvAsInt("UNGUESSABLE_PATH_LENGTH", 10),
+		PathAlphabet:            getEnv("PATH_ALPHABET", utils.DefaultPathAlphabet),
+		CustomPathMaxLength:     getEnvAsInt("CUSTOM_PATH_MAX_LENGTH", 64),
+		DefaultSuffixOption:     getEnv("DEFAULT_SUFFIX_OPTION", "UNGUESSABLE"),
+		HostSuffixOptions:       getEnvAsMap("HOST_SUFFIX_OPTIONS", map[string]string{}),
+		UnrecognizedParamPolicy: getEnv("UNRECOGNIZED_PARAM_POLICY", "WARN"),
+		PathGenerationAttempts:  getEnvAsInt("PATH_GENERATION_ATTEMPTS", 5),
+		MaxPathLengthGrowth:     getEnvAsInt("MAX_PATH_LENGTH_GROWTH", 2),
+		URLScheme:               getEnv("URL_SCHEME", "https"),
+		DomainAllowList:         getEnvAsSlice("DOMAIN_ALLOW_LIST", []string{}),
+		FallbackDomainAllowList: getEnvAsSlice("FALLBACK_DOMAIN_ALLOW_LIST", []string{}),
+		LogLevel:                getEnv("LOG_LEVEL", "info"),
+		AdminAPIKey:             getEnv("ADMIN_API_KEY", ""),
+		ConversionAPIKey:        getEnv("CONVERSION_API_KEY", ""),
+		StatsAPIKey:             getEnv("STATS_API_KEY", ""),
+		DomainConfigFile:        getEnv("DOMAIN_CONFIG_FILE", ""),
+		ClickTracking:           getEnvAsBool("CLICK_TRACKING", true),
+		EventBufferSize:         getEnvAsInt("EVENT_BUFFER_SIZE", 10000),
+		EventBatchSize:          getEnvAsInt("EVENT_BATCH_SIZE", 500),
+		EventFlushIntervalMs:    getEnvAsInt("EVENT_FLUSH_INTERVAL_MS", 1000),
+		AttributionWindowMins:   getEnvAsInt("ATTRIBUTION_WINDOW_MINUTES", 120),
+		RollupIntervalSeconds:   getEnvAsInt("ROLLUP_INTERVAL_SECONDS", 60),
+		EventRetentionDays:      getEnvAsInt("EVENT_RETENTION_DAYS", 90),
+		RunMigrations:           getEnvAsBool("RUN_MIGRATIONS", false),
 	}
 }
 
@@ -46,6 +90,23 @@ func getEnvAsSlice(key string, defaultVal []string) []string {
 	return defaultVal
 }
 
+func getEnvAsMap(key string, defaultVal map[string]string) map[string]string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultVal
+	}
+
+	result := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		result[strings.ToLower(strings.TrimSpace(k))] = strings.TrimSpace(v)
+	}
+	return result
+}
+
 func getEnvAsInt(name string, defaultVal int) int {
 	if valStr, ok := os.LookupEnv(name); ok {
 		if val, err := strconv.Atoi(valStr); err == nil {
@@ -54,3 +115,26 @@ func getEnvAsInt(name string, defaultVal int) int {
 	}
 	return defaultVal
 }
+
+func getEnvAsBool(name string, defaultVal bool) bool {
+	if valStr, ok := os.LookupEnv(name); ok {
+		if val, err := strconv.ParseBool(valStr); err == nil {
+			return val
+		}
+	}
+	return defaultVal
+}
+
+// PathSettings returns the global path settings as domain settings, the base
+// per-host overrides apply to.
+func (c *Config) PathSettings() models.DomainSettings {
+	settings := models.DomainSettings{
+		ShortPathLength:       c.ShortPathLength,
+		UnguessablePathLength: c.UnguessablePathLength,
+		PathAlphabet:          c.PathAlphabet,
+	}
+	if settings.PathAlphabet == "" {
+		settings.PathAlphabet = utils.DefaultPathAlphabet
+	}
+	return settings
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"dynamic-links-generator/api/models"
+	"dynamic-links-generator/utils"
+)
+
+// LoadDomainSettings reads per-host overrides from the JSON file at
+// DOMAIN_CONFIG_FILE, mapping each short link host to its settings, and
+// validates them on top of the global settings. No file yields no overrides.
+func LoadDomainSettings(cfg *Config) (map[string]models.DomainSettings, error) {
+	settings := map[string]models.DomainSettings{}
+	path := cfg.DomainConfigFile
+	if path == "" {
+		return settings, nil
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read domain config file: %w", err)
+	}
+
+	var raw map[string]models.DomainSettings
+	if err := json.Unmarshal(contents, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse domain config file: %w", err)
+	}
+
+	for host, hostSettings := range raw {
+		normalized, err := utils.NormalizeHost(host)
+		if err != nil {
+			return nil, err
+		}
+		hostSettings = hostSettings.Normalize()
+		if err := hostSettings.ValidateOverride(cfg.PathSettings()); err != nil {
+			return nil, fmt.Errorf("invalid settings for %s: %w", host, err)
+		}
+		settings[normalized] = hostSettings
+	}
+	return settings, nil
+}
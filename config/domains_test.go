@@ -0,0 +1,45 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDomainSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{"valid", `{"Brand.Page.Link": {"shortPathLength": 8, "pathPrefixes": ["/links/"]}}`, false},
+		{"weak alphabet under the global length", `{"brand.page.link": {"pathAlphabet": "ab"}}`, true},
+		{"short longer than the global unguessable", `{"brand.page.link": {"shortPathLength": 12}}`, true},
+		{"custom default", `{"brand.page.link": {"defaultSuffixOption": "CUSTOM"}}`, true},
+		{"malformed", `{"brand.page.link": []}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "domains.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatalf("failed to write domain config file: %s", err)
+			}
+
+			settings, err := LoadDomainSettings(&Config{ShortPathLength: 6, UnguessablePathLength: 10, DomainConfigFile: path})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 8, settings["brand.page.link"].ShortPathLength)
+			assert.Equal(t, []string{"links"}, settings["brand.page.link"].PathPrefixes)
+		})
+	}
+
+	settings, err := LoadDomainSettings(&Config{})
+	assert.NoError(t, err)
+	assert.Empty(t, settings)
+}
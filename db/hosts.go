@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"dynamic-links-generator/utils"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Statements moving the rows of host $1 to the normalized host $2. Rows that
+// would collide with one already stored under $2 are left behind: the links
+// stay under the old host, and duplicate domains and apps are dropped.
+var renameHostStatements = []string{
+	// Like 0003, a SHORT link already stored under the new host stays the
+	// canonical one and the other is moved out of the deduplication set.
+	`
+    UPDATE dynamic_links d
+    SET is_unguessable_path = TRUE
+    WHERE d.host = $1 AND d.is_unguessable_path = FALSE AND d.is_custom_path = FALSE
+      AND EXISTS (
+        SELECT 1 FROM dynamic_links o
+        WHERE o.host = $2 AND md5(o.query_params) = md5(d.query_params) AND o.query_params = d.query_params
+          AND o.is_unguessable_path = FALSE AND o.is_custom_path = FALSE
+      )`,
+	`
+    UPDATE dynamic_links d
+    SET host = $2
+    WHERE d.host = $1
+      AND NOT EXISTS (SELECT 1 FROM dynamic_links o WHERE o.host = $2 AND o.path = d.path)`,
+	`
+    INSERT INTO domains (host, settings, created_at)
+    SELECT $2, settings, created_at FROM domains WHERE host = $1
+    ON CONFLICT (host) DO NOTHING`,
+	`DELETE FROM domains WHERE host = $1`,
+	`
+    UPDATE host_ios_apps a
+    SET host = $2
+    WHERE a.host = $1
+      AND NOT EXISTS (SELECT 1 FROM host_ios_apps o WHERE o.host = $2 AND o.bundle_id = a.bundle_id)`,
+	`DELETE FROM host_ios_apps WHERE host = $1`,
+	`
+    UPDATE host_android_apps a
+    SET host = $2
+    WHERE a.host = $1
+      AND NOT EXISTS (
+        SELECT 1 FROM host_android_apps o
+        WHERE o.host = $2 AND o.package_name = a.package_name AND o.sha256_cert_fingerprint = a.sha256_cert_fingerprint
+      )`,
+	`DELETE FROM host_android_apps WHERE host = $1`,
+}
+
+// normalizeStoredHosts rewrites hosts stored before lookups were normalized,
+// e.g. "Example.com" or "münchen.example", so their links keep resolving.
+// Link events keep the host they were recorded with.
+func normalizeStoredHosts(ctx context.Context, tx *sql.Tx) error {
+	hosts, err := storedHosts(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, host := range hosts {
+		normalized, err := utils.NormalizeHost(host)
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("host", host).
+				Msg("Leaving unparsable host as stored")
+			continue
+		}
+		if normalized == host {
+			continue
+		}
+
+		for _, stmt := range renameHostStatements {
+			if _, err := tx.ExecContext(ctx, stmt, host, normalized); err != nil {
+				return fmt.Errorf("failed to normalize host %q: %w", host, err)
+			}
+		}
+
+		var left int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM dynamic_links WHERE host = $1`, host).Scan(&left); err != nil {
+			return fmt.Errorf("failed to normalize host %q: %w", host, err)
+		}
+		if left > 0 {
+			log.Warn().
+				Str("host", host).
+				Str("normalized_host", normalized).
+				Int("links", left).
+				Msg("Links clash with existing paths on the normalized host and no longer resolve")
+		}
+		log.Info().
+			Str("host", host).
+			Str("normalized_host", normalized).
+			Msg("Normalized stored host")
+	}
+	return nil
+}
+
+func storedHosts(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+    SELECT host FROM dynamic_links
+    UNION SELECT host FROM domains
+    UNION SELECT host FROM host_ios_apps
+    UNION SELECT host FROM host_android_apps`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored hosts: %w", err)
+	}
+	defer rows.Close()
+
+	var hosts []string
+	for rows.Next() {
+		var host string
+		if err := rows.Scan(&host); err != nil {
+			return nil, fmt.Errorf("failed to list stored hosts: %w", err)
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts, rows.Err()
+}
@@ -0,0 +1,285 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Arbitrary key shared by every instance so that concurrent startups apply
+// each migration exactly once.
+const migrationLockKey = 7283154901
+
+// Steps run in Go after a migration's up script, in the same transaction,
+// for data changes SQL cannot express.
+var dataMigrations = map[int]func(ctx context.Context, tx *sql.Tx) error{
+	11: normalizeStoredHosts,
+}
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Current int
+	Applied []Migration
+	Pending []Migration
+}
+
+func LoadMigrations() ([]Migration, error) {
+	return loadMigrations(migrationFS)
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, file := range files {
+		match := migrationFilePattern.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", file)
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", file, err)
+		}
+
+		contents, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %q: %w", file, err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d used by %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(contents)
+		} else {
+			m.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+func (d *DB) MigrateUp(ctx context.Context) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if err := d.ensureMigrationTable(ctx); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, m := range migrations {
+		ran, err := d.runMigration(ctx, m, true)
+		if err != nil {
+			return applied, err
+		}
+		if ran {
+			applied++
+		}
+	}
+
+	log.Info().
+		Int("applied", applied).
+		Msg("Database migrations are up to date")
+	return applied, nil
+}
+
+func (d *DB) MigrateDown(ctx context.Context, steps int) (int, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return 0, err
+	}
+	if err := d.ensureMigrationTable(ctx); err != nil {
+		return 0, err
+	}
+
+	versions, err := d.appliedVersions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	reverted := 0
+	for i := len(migrations) - 1; i >= 0 && reverted < steps; i-- {
+		m := migrations[i]
+		if !versions[m.Version] {
+			continue
+		}
+
+		ran, err := d.runMigration(ctx, m, false)
+		if err != nil {
+			return reverted, err
+		}
+		if ran {
+			reverted++
+		}
+	}
+
+	log.Info().
+		Int("reverted", reverted).
+		Msg("Database migrations reverted")
+	return reverted, nil
+}
+
+func (d *DB) MigrationStatus(ctx context.Context) (*MigrationStatus, error) {
+	migrations, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.ensureMigrationTable(ctx); err != nil {
+		return nil, err
+	}
+
+	versions, err := d.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{}
+	for _, m := range migrations {
+		if versions[m.Version] {
+			status.Applied = append(status.Applied, m)
+			status.Current = m.Version
+		} else {
+			status.Pending = append(status.Pending, m)
+		}
+	}
+
+	return status, nil
+}
+
+func (d *DB) ensureMigrationTable(ctx context.Context) error {
+	const stmt = `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+      version    INTEGER     PRIMARY KEY,
+      name       TEXT        NOT NULL,
+      applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    )`
+
+	if _, err := d.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) appliedVersions(ctx context.Context) (map[int]bool, error) {
+	rows, err := d.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	versions := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+		}
+		versions[version] = true
+	}
+	return versions, rows.Err()
+}
+
+// runMigration applies or reverts a single migration in its own transaction.
+// The applied state is re-checked under the advisory lock, so it reports
+// false when another instance got there first.
+func (d *DB) runMigration(ctx context.Context, m Migration, up bool) (bool, error) {
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin migration %d: %w", m.Version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, migrationLockKey); err != nil {
+		return false, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	var applied bool
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`,
+		m.Version,
+	).Scan(&applied)
+	if err != nil {
+		return false, fmt.Errorf("failed to check migration %d: %w", m.Version, err)
+	}
+	if applied == up {
+		return false, nil
+	}
+
+	script, record := m.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`
+	if !up {
+		script, record = m.Down, `DELETE FROM schema_migrations WHERE version = $1`
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return false, fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+	}
+	if data := dataMigrations[m.Version]; up && data != nil {
+		if err := data(ctx, tx); err != nil {
+			return false, fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+	}
+
+	args := []any{m.Version}
+	if up {
+		args = append(args, m.Name)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return false, fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit migration %d: %w", m.Version, err)
+	}
+
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+	log.Info().
+		Int("version", m.Version).
+		Str("name", m.Name).
+		Str("direction", direction).
+		Msg("Migration executed")
+	return true, nil
+}
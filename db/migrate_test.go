@@ -0,0 +1,237 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+	os.Exit(m.Run())
+}
+
+func TestLoadMigrations_Embedded(t *testing.T) {
+	migrations, err := LoadMigrations()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "migration versions must be contiguous")
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+	}
+	assert.Contains(t, migrations[0].Up, "CREATE TABLE IF NOT EXISTS dynamic_links")
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []int
+		wantErr bool
+	}{
+		{
+			name: "sorted by version",
+			fsys: fstest.MapFS{
+				"migrations/0002_second.up.sql":   {Data: []byte("up 2")},
+				"migrations/0002_second.down.sql": {Data: []byte("down 2")},
+				"migrations/0001_first.up.sql":    {Data: []byte("up 1")},
+				"migrations/0001_first.down.sql":  {Data: []byte("down 1")},
+			},
+			want: []int{1, 2},
+		},
+		{
+			name: "missing down file",
+			fsys: fstest.MapFS{
+				"migrations/0001_first.up.sql": {Data: []byte("up 1")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid file name",
+			fsys: fstest.MapFS{
+				"migrations/first.sql": {Data: []byte("up 1")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflicting names for a version",
+			fsys: fstest.MapFS{
+				"migrations/0001_first.up.sql":   {Data: []byte("up 1")},
+				"migrations/0001_other.down.sql": {Data: []byte("down 1")},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.fsys)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			var versions []int
+			for _, m := range migrations {
+				versions = append(versions, m.Version)
+			}
+			assert.Equal(t, tt.want, versions)
+		})
+	}
+}
+
+func TestMigrateUp_SkipsAppliedMigrations(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer sqlDB.Close()
+	database := &DB{DB: sqlDB}
+
+	migrations, err := LoadMigrations()
+	assert.NoError(t, err)
+
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for i, m := range migrations {
+		mock.ExpectBegin()
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WithArgs(m.Version).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(i == 0))
+		if i == 0 {
+			mock.ExpectRollback()
+			continue
+		}
+		mock.ExpectExec(".+").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		if dataMigrations[m.Version] != nil {
+			mock.ExpectQuery(`SELECT host FROM dynamic_links`).
+				WillReturnRows(sqlmock.NewRows([]string{"host"}))
+		}
+		mock.ExpectExec(`INSERT INTO schema_migrations`).
+			WithArgs(m.Version, m.Name).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+	}
+
+	applied, err := database.MigrateUp(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, len(migrations)-1, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNormalizeStoredHosts(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock database: %s", err)
+	}
+	defer sqlDB.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT host FROM dynamic_links`).
+		WillReturnRows(sqlmock.NewRows([]string{"host"}).
+			AddRow("example.page.link").
+			AddRow("Example.COM").
+			AddRow("münchen.example"))
+	for _, host := range []struct{ from, to string }{
+		{"Example.COM", "example.com"},
+		{"münchen.example", "xn--mnchen-3ya.example"},
+	} {
+		for range renameHostStatements {
+			mock.ExpectExec(".+").
+				WithArgs(host.from, host.to).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM dynamic_links`).
+			WithArgs(host.from).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	}
+	mock.ExpectCommit()
+
+	tx, err := sqlDB.Begin()
+	assert.NoError(t, err)
+	assert.NoError(t, normalizeStoredHosts(context.Background(), tx))
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// testPostgresConn returns a connection to TEST_DATABASE_URL whose search path
+// is a fresh, throwaway schema. Tests using it are skipped without one.
+func testPostgresConn(t *testing.T) *sql.Conn {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	sqlDB, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %s", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		t.Fatalf("failed to connect to database: %s", err)
+	}
+	schema := fmt.Sprintf("migrate_test_%d", time.Now().UnixNano())
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("CREATE SCHEMA %s; SET search_path TO %s", schema, schema)); err != nil {
+		t.Fatalf("failed to create schema: %s", err)
+	}
+	t.Cleanup(func() {
+		conn.ExecContext(context.Background(), fmt.Sprintf("DROP SCHEMA %s CASCADE", schema))
+		conn.Close()
+	})
+	return conn
+}
+
+func TestCreateDynamicLinksMigration_AdoptsExistingTable(t *testing.T) {
+	conn := testPostgresConn(t)
+	ctx := context.Background()
+
+	// The table as environments set it up by hand before migrations existed.
+	_, err := conn.ExecContext(ctx, `
+    CREATE TABLE dynamic_links (
+      host                TEXT    NOT NULL,
+      path                TEXT    NOT NULL,
+      query_params        TEXT    NOT NULL,
+      is_unguessable_path BOOLEAN NOT NULL DEFAULT FALSE
+    );
+    INSERT INTO dynamic_links (host, path, query_params) VALUES
+      ('example.page.link', 'abc123', 'link=https%3A%2F%2Fexample.com%2Fa'),
+      ('example.page.link', 'def456', 'link=https%3A%2F%2Fexample.com%2Fb')`)
+	assert.NoError(t, err)
+
+	migrations, err := LoadMigrations()
+	assert.NoError(t, err)
+	_, err = conn.ExecContext(ctx, migrations[0].Up)
+	assert.NoError(t, err)
+
+	var rows, ids int
+	err = conn.QueryRowContext(ctx, `SELECT COUNT(*), COUNT(DISTINCT id) FROM dynamic_links`).Scan(&rows, &ids)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, 2, ids)
+
+	_, err = conn.ExecContext(ctx, `INSERT INTO dynamic_links (host, path, query_params) VALUES ('example.page.link', 'abc123', 'x')`)
+	assert.ErrorContains(t, err, "dynamic_links_host_path_key")
+
+	// Running it again changes nothing.
+	_, err = conn.ExecContext(ctx, migrations[0].Up)
+	assert.NoError(t, err)
+}
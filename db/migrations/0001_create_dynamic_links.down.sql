@@ -0,0 +1,3 @@
+-- The table may predate migrations and hold every short link, so reverting
+-- keeps it and only drops what 0001 added on top.
+DROP INDEX IF EXISTS dynamic_links_host_query_params_idx;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS dynamic_links (
+    id                  BIGSERIAL   PRIMARY KEY,
+    host                TEXT        NOT NULL,
+    path                TEXT        NOT NULL,
+    query_params        TEXT        NOT NULL,
+    is_unguessable_path BOOLEAN     NOT NULL DEFAULT FALSE,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT dynamic_links_host_path_key UNIQUE (host, path)
+);
+
+-- Tables set up by hand before migrations existed are adopted with their
+-- rows: later migrations order links by id, and collision detection relies on
+-- the (host, path) constraint.
+ALTER TABLE dynamic_links
+    ADD COLUMN IF NOT EXISTS id                  BIGSERIAL,
+    ADD COLUMN IF NOT EXISTS is_unguessable_path BOOLEAN     NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conrelid = 'dynamic_links'::regclass AND contype = 'p'
+    ) THEN
+        ALTER TABLE dynamic_links ADD PRIMARY KEY (id);
+    END IF;
+
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conrelid = 'dynamic_links'::regclass AND conname = 'dynamic_links_host_path_key'
+    ) THEN
+        ALTER TABLE dynamic_links
+            ADD CONSTRAINT dynamic_links_host_path_key UNIQUE (host, path);
+    END IF;
+END $$;
+
+-- Lookup index for FindExistingShortLink. query_params is hashed because long
+-- links can exceed the btree row size limit.
+CREATE INDEX IF NOT EXISTS dynamic_links_host_query_params_idx
+    ON dynamic_links (host, md5(query_params))
+    WHERE is_unguessable_path = FALSE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS host_android_apps;
+DROP TABLE IF EXISTS host_ios_apps;
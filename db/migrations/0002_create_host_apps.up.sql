@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS host_ios_apps (
+    host      TEXT NOT NULL,
+    team_id   TEXT NOT NULL,
+    bundle_id TEXT NOT NULL,
+    PRIMARY KEY (host, bundle_id)
+);
+
+CREATE TABLE IF NOT EXISTS host_android_apps (
+    host                    TEXT NOT NULL,
+    package_name            TEXT NOT NULL,
+    sha256_cert_fingerprint TEXT NOT NULL,
+    PRIMARY KEY (host, package_name, sha256_cert_fingerprint)
+);
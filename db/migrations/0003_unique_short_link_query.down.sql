@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS dynamic_links_host_query_params_key;
+
+CREATE INDEX IF NOT EXISTS dynamic_links_host_query_params_idx
+    ON dynamic_links (host, md5(query_params))
+    WHERE is_unguessable_path = FALSE;
@@ -0,0 +1,21 @@
+-- Links created concurrently before this migration may share (host,
+-- query_params). Keep the oldest row as the canonical short link and move the
+-- others out of the deduplication set; they keep resolving as before.
+UPDATE dynamic_links d
+   SET is_unguessable_path = TRUE
+ WHERE d.is_unguessable_path = FALSE
+   AND EXISTS (
+       SELECT 1
+         FROM dynamic_links o
+        WHERE o.host = d.host
+          AND md5(o.query_params) = md5(d.query_params)
+          AND o.query_params = d.query_params
+          AND o.is_unguessable_path = FALSE
+          AND o.id < d.id
+   );
+
+DROP INDEX IF EXISTS dynamic_links_host_query_params_idx;
+
+CREATE UNIQUE INDEX dynamic_links_host_query_params_key
+    ON dynamic_links (host, md5(query_params))
+    WHERE is_unguessable_path = FALSE;
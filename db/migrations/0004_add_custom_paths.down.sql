@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS dynamic_links_host_query_params_key;
+
+-- Keep vanity links resolvable but out of SHORT deduplication.
+UPDATE dynamic_links SET is_unguessable_path = TRUE WHERE is_custom_path = TRUE;
+
+ALTER TABLE dynamic_links DROP COLUMN IF EXISTS is_custom_path;
+
+CREATE UNIQUE INDEX dynamic_links_host_query_params_key
+    ON dynamic_links (host, md5(query_params))
+    WHERE is_unguessable_path = FALSE;
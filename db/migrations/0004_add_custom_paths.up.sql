@@ -0,0 +1,10 @@
+ALTER TABLE dynamic_links
+    ADD COLUMN IF NOT EXISTS is_custom_path BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Vanity links are never handed out by SHORT deduplication, so they are kept
+-- out of the (host, query_params) uniqueness set.
+DROP INDEX IF EXISTS dynamic_links_host_query_params_key;
+
+CREATE UNIQUE INDEX dynamic_links_host_query_params_key
+    ON dynamic_links (host, md5(query_params))
+    WHERE is_unguessable_path = FALSE AND is_custom_path = FALSE;
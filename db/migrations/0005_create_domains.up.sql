@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS domains (
+    host       TEXT        PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Hosts that already have links stay usable once the registry is enforced.
+INSERT INTO domains (host)
+SELECT DISTINCT host FROM dynamic_links
+ON CONFLICT (host) DO NOTHING;
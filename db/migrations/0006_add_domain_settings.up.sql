@@ -0,0 +1,2 @@
+ALTER TABLE domains
+    ADD COLUMN IF NOT EXISTS settings JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS link_events (
+    id               BIGSERIAL   PRIMARY KEY,
+    host             TEXT        NOT NULL,
+    path             TEXT        NOT NULL,
+    event_type       TEXT        NOT NULL,
+    source           TEXT        NOT NULL,
+    platform         TEXT        NOT NULL,
+    referrer         TEXT        NOT NULL DEFAULT '',
+    ip_prefix        TEXT        NOT NULL DEFAULT '',
+    user_agent_class TEXT        NOT NULL,
+    occurred_at      TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS link_events_host_path_occurred_at_idx
+    ON link_events (host, path, occurred_at);
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS click_fingerprints (
+    id             TEXT        PRIMARY KEY,
+    requested_link TEXT        NOT NULL,
+    host           TEXT        NOT NULL,
+    path           TEXT        NOT NULL,
+    app_id         TEXT        NOT NULL,
+    platform       TEXT        NOT NULL,
+    ip_prefix      TEXT        NOT NULL,
+    device_model   TEXT        NOT NULL DEFAULT '',
+    os_version     TEXT        NOT NULL DEFAULT '',
+    language       TEXT        NOT NULL DEFAULT '',
+    timezone       TEXT        NOT NULL DEFAULT '',
+    screen_width   INTEGER     NOT NULL DEFAULT 0,
+    screen_height  INTEGER     NOT NULL DEFAULT 0,
+    clicked_at     TIMESTAMPTZ NOT NULL,
+    matched_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS click_fingerprints_unmatched_idx
+    ON click_fingerprints (ip_prefix, app_id, clicked_at)
+    WHERE matched_at IS NULL;
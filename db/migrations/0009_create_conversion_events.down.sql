@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS conversion_events;
+
+DROP INDEX IF EXISTS link_events_utm_campaign_occurred_at_idx;
+
+ALTER TABLE link_events
+    DROP COLUMN IF EXISTS utm_source,
+    DROP COLUMN IF EXISTS utm_medium,
+    DROP COLUMN IF EXISTS utm_campaign;
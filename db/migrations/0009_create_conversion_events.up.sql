@@ -0,0 +1,31 @@
+ALTER TABLE link_events
+    ADD COLUMN IF NOT EXISTS utm_source   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS utm_medium   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS utm_campaign TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS link_events_utm_campaign_occurred_at_idx
+    ON link_events (utm_campaign, occurred_at);
+
+CREATE TABLE IF NOT EXISTS conversion_events (
+    id             BIGSERIAL      PRIMARY KEY,
+    host           TEXT           NOT NULL,
+    path           TEXT           NOT NULL,
+    attribution_id TEXT           NOT NULL DEFAULT '',
+    event_name     TEXT           NOT NULL,
+    value          NUMERIC(18, 4),
+    currency       TEXT           NOT NULL DEFAULT '',
+    platform       TEXT           NOT NULL,
+    utm_source     TEXT           NOT NULL DEFAULT '',
+    utm_medium     TEXT           NOT NULL DEFAULT '',
+    utm_campaign   TEXT           NOT NULL DEFAULT '',
+    utm_term       TEXT           NOT NULL DEFAULT '',
+    utm_content    TEXT           NOT NULL DEFAULT '',
+    occurred_at    TIMESTAMPTZ    NOT NULL,
+    received_at    TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS conversion_events_host_path_occurred_at_idx
+    ON conversion_events (host, path, occurred_at);
+
+CREATE INDEX IF NOT EXISTS conversion_events_utm_campaign_occurred_at_idx
+    ON conversion_events (utm_campaign, occurred_at);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS rollup_state;
+DROP TABLE IF EXISTS link_event_rollups_daily;
+DROP TABLE IF EXISTS link_event_rollups_hourly;
+
+DROP INDEX IF EXISTS link_events_occurred_at_idx;
+DROP INDEX IF EXISTS link_events_recorded_at_idx;
+
+ALTER TABLE link_events
+    DROP COLUMN IF EXISTS recorded_at;
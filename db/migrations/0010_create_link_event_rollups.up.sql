@@ -0,0 +1,45 @@
+-- When a row was written, as opposed to when the event happened, so the
+-- aggregator can find late events.
+ALTER TABLE link_events
+    ADD COLUMN IF NOT EXISTS recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS link_events_recorded_at_idx
+    ON link_events (recorded_at);
+
+CREATE INDEX IF NOT EXISTS link_events_occurred_at_idx
+    ON link_events (occurred_at);
+
+-- utm_campaign is not part of the key: it comes from the link, so it is the
+-- same for every event of a (host, path).
+CREATE TABLE IF NOT EXISTS link_event_rollups_hourly (
+    host         TEXT        NOT NULL,
+    path         TEXT        NOT NULL,
+    platform     TEXT        NOT NULL,
+    event_type   TEXT        NOT NULL,
+    bucket       TIMESTAMPTZ NOT NULL,
+    utm_campaign TEXT        NOT NULL DEFAULT '',
+    count        BIGINT      NOT NULL,
+    PRIMARY KEY (host, path, platform, event_type, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS link_event_rollups_hourly_utm_campaign_idx
+    ON link_event_rollups_hourly (utm_campaign, bucket);
+
+CREATE TABLE IF NOT EXISTS link_event_rollups_daily (
+    host         TEXT        NOT NULL,
+    path         TEXT        NOT NULL,
+    platform     TEXT        NOT NULL,
+    event_type   TEXT        NOT NULL,
+    bucket       TIMESTAMPTZ NOT NULL,
+    utm_campaign TEXT        NOT NULL DEFAULT '',
+    count        BIGINT      NOT NULL,
+    PRIMARY KEY (host, path, platform, event_type, bucket)
+);
+
+CREATE INDEX IF NOT EXISTS link_event_rollups_daily_utm_campaign_idx
+    ON link_event_rollups_daily (utm_campaign, bucket);
+
+CREATE TABLE IF NOT EXISTS rollup_state (
+    name      TEXT        PRIMARY KEY,
+    watermark TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,2 @@
+-- The original spelling of normalized hosts is not kept, so they stay
+-- normalized.
@@ -0,0 +1,4 @@
+-- Hosts are now stored lowercased, with internationalized labels in punycode
+-- (see utils.NormalizeHost). Postgres cannot convert to punycode, so existing
+-- rows are rewritten by normalizeStoredHosts in db/hosts.go, which runs in
+-- this migration's transaction.
@@ -12,7 +12,12 @@ require (
 	github.com/stretchr/testify v1.10.0
 )
 
-require github.com/lib/pq v1.10.9 // indirect
+require github.com/lib/pq v1.10.9
+
+require (
+	golang.org/x/net v0.37.0
+	golang.org/x/text v0.23.0 // indirect
+)
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.5.2
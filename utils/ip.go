@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"net"
+	"strings"
+)
+
+// AnonymizeIP keeps the network part of addr, a /24 for IPv4 and a /48 for
+// IPv6, so stored events cannot be traced back to a single client. A port,
+// as found in http.Request.RemoteAddr, is ignored.
+func AnonymizeIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+
+	ip := net.ParseIP(strings.Trim(addr, "[]"))
+	if ip == nil {
+		return ""
+	}
+	if v4 := ip.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
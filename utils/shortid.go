@@ -2,27 +2,133 @@ package utils
 
 import (
 	"crypto/rand"
+	"fmt"
+	"math"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
 
+const DefaultPathAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Lower bounds for generated paths. SHORT paths only need room to avoid
+// collisions; UNGUESSABLE ones must also resist enumeration.
+const (
+	MinShortPathLength        = 4
+	MinUnguessableEntropyBits = 48
+)
+
 func GenerateDynamicLinkPath(length int) string {
-	const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	_, err := rand.Read(b)
-	if err != nil {
-		log.Panic().Err(err).Msg("Failed to generate random bytes")
-	}
+	return GenerateDynamicLinkPathFromAlphabet(length, DefaultPathAlphabet)
+}
 
-	for i := range b {
-		b[i] = alphanumeric[b[i]%byte(len(alphanumeric))]
-	}
+// GenerateDynamicLinkPathFromAlphabet draws each character uniformly from
+// alphabet. Random bytes at or above the largest multiple of len(alphabet) are
+// discarded so the modulo does not favour the first characters.
+func GenerateDynamicLinkPathFromAlphabet(length int, alphabet string) string {
+	n := len(alphabet)
+	limit := 256 - 256%n
 
-	id := string(b)
+	id := make([]byte, 0, length)
+	buf := make([]byte, length+length/4+1)
+	for len(id) < length {
+		if _, err := rand.Read(buf); err != nil {
+			log.Panic().Err(err).Msg("Failed to generate random bytes")
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			id = append(id, alphabet[int(b)%n])
+			if len(id) == length {
+				break
+			}
+		}
+	}
 
 	log.Debug().
-		Str("short_code", id).
+		Str("short_code", string(id)).
 		Msg("Generated alphanumeric short ID")
 
-	return id
+	return string(id)
+}
+
+func ValidatePathAlphabet(alphabet string) error {
+	if len(alphabet) < 2 || len(alphabet) > 256 {
+		return fmt.Errorf("path alphabet must contain between 2 and 256 characters, got %d", len(alphabet))
+	}
+
+	seen := map[rune]bool{}
+	for _, c := range alphabet {
+		if !isUnreservedPathChar(c) {
+			return fmt.Errorf("path alphabet contains unsupported character %q", c)
+		}
+		if seen[c] {
+			return fmt.Errorf("path alphabet contains duplicate character %q", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// ValidateShortPathLength checks the length of SHORT paths.
+func ValidateShortPathLength(length int) error {
+	if length < MinShortPathLength {
+		return fmt.Errorf("short path length must be at least %d, got %d", MinShortPathLength, length)
+	}
+	return nil
+}
+
+// ValidateUnguessablePathLength checks that UNGUESSABLE paths of length over
+// alphabet have enough entropy.
+func ValidateUnguessablePathLength(length int, alphabet string) error {
+	if bits := PathEntropyBits(length, alphabet); bits < MinUnguessableEntropyBits {
+		return fmt.Errorf("unguessable paths of %d characters from a %d character alphabet have %.1f bits of entropy, at least %d are required",
+			length, len(alphabet), bits, MinUnguessableEntropyBits)
+	}
+	return nil
+}
+
+func ValidateCustomPath(path string, maxLength int) error {
+	if path == "" {
+		return fmt.Errorf("custom path is required")
+	}
+	if len(path) > maxLength {
+		return fmt.Errorf("custom path must be at most %d characters", maxLength)
+	}
+	for _, c := range path {
+		if !isUnreservedPathChar(c) {
+			return fmt.Errorf("custom path contains unsupported character %q", c)
+		}
+	}
+	return nil
+}
+
+func ValidatePathPrefix(prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("path prefix must not be empty")
+	}
+	for _, segment := range strings.Split(prefix, "/") {
+		if segment == "" {
+			return fmt.Errorf("path prefix %q contains an empty segment", prefix)
+		}
+		for _, c := range segment {
+			if !isUnreservedPathChar(c) {
+				return fmt.Errorf("path prefix %q contains unsupported character %q", prefix, c)
+			}
+		}
+	}
+	return nil
+}
+
+// PathEntropyBits is the keyspace size, in bits, of paths of the given length.
+func PathEntropyBits(length int, alphabet string) float64 {
+	return float64(length) * math.Log2(float64(len(alphabet)))
+}
+
+func isUnreservedPathChar(c rune) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		strings.ContainsRune("-_~", c)
 }
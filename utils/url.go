@@ -2,11 +2,14 @@ package utils
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"slices"
 	"strings"
+	"unicode"
 
 	"github.com/rs/zerolog/log"
+	"golang.org/x/net/idna"
 )
 
 func ValidateURLScheme(urlStr string) error {
@@ -42,10 +45,128 @@ func CleanHost(raw string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	host := u.Hostname()
+	host, err := NormalizeHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
 	log.Debug().
 		Str("host", host).
 		Msg("Cleaned host")
 
 	return host, nil
 }
+
+// SplitURLPrefix splits a domain URI prefix such as "https://example.com/links"
+// into its normalized host and the path prefix without surrounding slashes.
+func SplitURLPrefix(raw string) (string, string, error) {
+	host, err := CleanHost(raw)
+	if err != nil {
+		return "", "", err
+	}
+
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", "", err
+	}
+
+	return host, strings.Trim(u.Path, "/"), nil
+}
+
+// hostProfile follows IDNA lookup rules but, unlike idna.Lookup, tolerates
+// underscores and other non-LDH characters already seen in stored hosts.
+var hostProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.Transitional(false),
+	idna.StrictDomainName(false),
+)
+
+// NormalizeHost lowercases host and converts internationalized labels to
+// punycode, so "münchen.example" and "xn--mnchen-3ya.example" compare equal.
+func NormalizeHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.TrimSpace(host), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return strings.ToLower(host), nil
+	}
+
+	ascii, err := hostProfile.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid internationalized host %q: %w", host, err)
+	}
+	return ascii, nil
+}
+
+// IsMixedScriptHost reports whether a label of host mixes letters from scripts
+// that are not normally written together, such as a Cyrillic "а" in an
+// otherwise Latin "pаypal.com". Han may be combined with the Japanese kana,
+// Hangul or Bopomofo, and with Latin, as UTS #39 allows.
+func IsMixedScriptHost(host string) bool {
+	unicodeHost, err := hostProfile.ToUnicode(host)
+	if err != nil {
+		unicodeHost = host
+	}
+
+	for _, label := range strings.Split(unicodeHost, ".") {
+		if isMixedScriptLabel(label) {
+			return true
+		}
+	}
+	return false
+}
+
+var checkedScripts = []string{
+	"Latin", "Greek", "Cyrillic", "Armenian", "Hebrew", "Arabic", "Georgian",
+	"Han", "Hiragana", "Katakana", "Hangul", "Bopomofo", "Thai", "Devanagari",
+}
+
+var compatibleScriptSets = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Hangul"},
+	{"Latin", "Han", "Bopomofo"},
+}
+
+func isMixedScriptLabel(label string) bool {
+	seen := map[string]bool{}
+	for _, c := range label {
+		if !unicode.IsLetter(c) {
+			continue
+		}
+		script := "Other"
+		for _, name := range checkedScripts {
+			if unicode.Is(unicode.Scripts[name], c) {
+				script = name
+				break
+			}
+		}
+		seen[script] = true
+	}
+	if len(seen) <= 1 {
+		return false
+	}
+
+	for _, set := range compatibleScriptSets {
+		compatible := true
+		for script := range seen {
+			if !slices.Contains(set, script) {
+				compatible = false
+				break
+			}
+		}
+		if compatible {
+			return false
+		}
+	}
+	return true
+}
+
+func PlayStoreURL(packageName string) string {
+	return "https://play.google.com/store/apps/details?id=" + url.QueryEscape(packageName)
+}
+
+func AppStoreURL(appStoreID string) string {
+	return "https://apps.apple.com/app/id" + url.PathEscape(appStoreID)
+}
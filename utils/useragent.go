@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+type Platform string
+
+const (
+	PlatformAndroid Platform = "ANDROID"
+	PlatformIOS     Platform = "IOS"
+	PlatformIPad    Platform = "IPAD"
+	PlatformDesktop Platform = "DESKTOP"
+	PlatformOther   Platform = "OTHER"
+)
+
+var Platforms = []Platform{
+	PlatformAndroid,
+	PlatformIOS,
+	PlatformIPad,
+	PlatformDesktop,
+	PlatformOther,
+}
+
+const (
+	UserAgentBrowser      = "BROWSER"
+	UserAgentInAppBrowser = "IN_APP_BROWSER"
+	UserAgentCrawler      = "CRAWLER"
+	UserAgentUnknown      = "UNKNOWN"
+)
+
+// Markers added by apps that open links in an embedded web view.
+var inAppBrowserAgents = []string{
+	"fban", "fbav", "instagram", "line/", "micromessenger", "snapchat", "tiktok", "gsa/",
+}
+
+var (
+	androidDevicePattern = regexp.MustCompile(`android ([\d.]+);\s*([^;)]+?)(?:\s+build/[^;)]*)?[;)]`)
+	appleDevicePattern   = regexp.MustCompile(`(iphone|ipad|ipod)(?:[^)]*?) os (\d+(?:_\d+)*)`)
+)
+
+var crawlerAgents = []string{
+	"slackbot",
+	"facebookexternalhit",
+	"facebookcatalog",
+	"twitterbot",
+	"whatsapp",
+	"discordbot",
+	"linkedinbot",
+	"telegrambot",
+	"skypeuripreview",
+}
+
+func IsCrawler(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	for _, agent := range crawlerAgents {
+		if strings.Contains(ua, agent) {
+			return true
+		}
+	}
+	return false
+}
+
+func DetectPlatform(userAgent string) Platform {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case ua == "":
+		return PlatformOther
+	case strings.Contains(ua, "android"):
+		return PlatformAndroid
+	case strings.Contains(ua, "ipad"):
+		return PlatformIPad
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"):
+		return PlatformIOS
+	case strings.Contains(ua, "windows nt"),
+		strings.Contains(ua, "macintosh"),
+		strings.Contains(ua, "x11"),
+		strings.Contains(ua, "cros"):
+		return PlatformDesktop
+	}
+	return PlatformOther
+}
+
+// UserAgentClass buckets a user agent coarsely enough to be stored with link
+// events in place of the raw string.
+func UserAgentClass(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	switch {
+	case ua == "":
+		return UserAgentUnknown
+	case IsCrawler(ua):
+		return UserAgentCrawler
+	}
+	for _, agent := range inAppBrowserAgents {
+		if strings.Contains(ua, agent) {
+			return UserAgentInAppBrowser
+		}
+	}
+	return UserAgentBrowser
+}
+
+// DeviceInfo extracts the device model and OS version a mobile browser
+// reports. On iOS the model is only the family (iPhone, iPad, iPod). Empty
+// strings mean the user agent does not tell.
+func DeviceInfo(userAgent string) (model, osVersion string) {
+	ua := strings.ToLower(userAgent)
+
+	if m := androidDevicePattern.FindStringSubmatch(ua); m != nil {
+		// Chrome's reduced user agent reports "Android 10; K" for every
+		// device, which would only cause false mismatches.
+		if strings.TrimSpace(m[2]) == "k" {
+			return "", ""
+		}
+		return strings.TrimSpace(m[2]), m[1]
+	}
+	if m := appleDevicePattern.FindStringSubmatch(ua); m != nil {
+		return m[1], strings.ReplaceAll(m[2], "_", ".")
+	}
+	return "", ""
+}
+
+// PrimaryLanguage returns the lowercase primary subtag of the first language
+// in an Accept-Language header or a BCP 47 tag, e.g. "en" for "en-US,en;q=0.9".
+func PrimaryLanguage(tag string) string {
+	first, _, _ := strings.Cut(tag, ",")
+	first, _, _ = strings.Cut(first, ";")
+	primary, _, _ := strings.Cut(strings.TrimSpace(first), "-")
+	primary, _, _ = strings.Cut(primary, "_")
+	return strings.ToLower(primary)
+}
@@ -251,6 +251,114 @@ func TestIsDomainAllowed(t *testing.T) {
 			allowList: allowList,
 			want:      true,
 		},
+		{
+			name:      "wildcard matches subdomain",
+			rawLink:   "https://a.b.example.com/x",
+			allowList: []string{"*.example.com"},
+			want:      true,
+		},
+		{
+			name:      "wildcard does not match apex",
+			rawLink:   "https://example.com",
+			allowList: []string{"*.example.com"},
+			want:      false,
+		},
+		{
+			name:      "wildcard does not match lookalike suffix",
+			rawLink:   "https://evilexample.com",
+			allowList: []string{"*.example.com"},
+			want:      false,
+		},
+		{
+			name:      "dot suffix matches apex",
+			rawLink:   "https://example.com",
+			allowList: []string{".example.com"},
+			want:      true,
+		},
+		{
+			name:      "dot suffix matches subdomain",
+			rawLink:   "https://sub.EXAMPLE.com",
+			allowList: []string{".example.com"},
+			want:      true,
+		},
+		{
+			name:      "path prefix match",
+			rawLink:   "https://example.com/app/open?id=1",
+			allowList: []string{"example.com/app/*"},
+			want:      true,
+		},
+		{
+			name:      "path prefix matches the prefix itself",
+			rawLink:   "https://example.com/app",
+			allowList: []string{"example.com/app/*"},
+			want:      true,
+		},
+		{
+			name:      "path prefix outside prefix",
+			rawLink:   "https://example.com/apple",
+			allowList: []string{"example.com/app/*"},
+			want:      false,
+		},
+		{
+			name:      "path prefix with dot segments",
+			rawLink:   "https://example.com/app/../admin",
+			allowList: []string{"example.com/app/*"},
+			want:      false,
+		},
+		{
+			name:      "exact path",
+			rawLink:   "https://example.com/app/",
+			allowList: []string{"example.com/app"},
+			want:      true,
+		},
+		{
+			name:      "exact path is case sensitive",
+			rawLink:   "https://example.com/App",
+			allowList: []string{"example.com/app"},
+			want:      false,
+		},
+		{
+			name:      "deny overrides wildcard",
+			rawLink:   "https://evil.example.com",
+			allowList: []string{"*.example.com", "!evil.example.com"},
+			want:      false,
+		},
+		{
+			name:      "deny listed before allow",
+			rawLink:   "https://example.com/admin/users",
+			allowList: []string{"!example.com/admin/*", "example.com"},
+			want:      false,
+		},
+		{
+			name:      "deny does not affect other hosts",
+			rawLink:   "https://good.example.com",
+			allowList: []string{"*.example.com", "!evil.example.com"},
+			want:      true,
+		},
+		{
+			name:      "unicode link matches punycode entry",
+			rawLink:   "https://münchen.example/karte",
+			allowList: []string{"xn--mnchen-3ya.example"},
+			want:      true,
+		},
+		{
+			name:      "punycode link matches unicode entry",
+			rawLink:   "https://xn--mnchen-3ya.example",
+			allowList: []string{"*.example", "!münchen.example"},
+			want:      false,
+		},
+		{
+			name:      "unicode wildcard entry",
+			rawLink:   "https://stadt.xn--mnchen-3ya.example",
+			allowList: []string{"*.münchen.example"},
+			want:      true,
+		},
+		{
+			name:      "deny alone allows nothing",
+			rawLink:   "https://other.com",
+			allowList: []string{"!evil.example.com"},
+			want:      false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -395,6 +503,30 @@ func TestCleanHost(t *testing.T) {
 			want:    "example.com",
 			wantErr: false,
 		},
+		{
+			name:    "unicode host",
+			raw:     "https://münchen.example",
+			want:    "xn--mnchen-3ya.example",
+			wantErr: false,
+		},
+		{
+			name:    "uppercase unicode host",
+			raw:     "MÜNCHEN.example",
+			want:    "xn--mnchen-3ya.example",
+			wantErr: false,
+		},
+		{
+			name:    "punycode host",
+			raw:     "https://XN--MNCHEN-3YA.example/path",
+			want:    "xn--mnchen-3ya.example",
+			wantErr: false,
+		},
+		{
+			name:    "invalid punycode",
+			raw:     "xn--zz.example",
+			want:    "",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -411,3 +543,334 @@ func TestCleanHost(t *testing.T) {
 		})
 	}
 }
+
+func TestDetectPlatform(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      Platform
+	}{
+		{
+			name:      "android phone",
+			userAgent: "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Mobile Safari/537.36",
+			want:      PlatformAndroid,
+		},
+		{
+			name:      "iphone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			want:      PlatformIOS,
+		},
+		{
+			name:      "ipad",
+			userAgent: "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			want:      PlatformIPad,
+		},
+		{
+			name:      "windows desktop",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36",
+			want:      PlatformDesktop,
+		},
+		{
+			name:      "mac desktop",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_4) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+			want:      PlatformDesktop,
+		},
+		{
+			name:      "command line client",
+			userAgent: "curl/8.5.0",
+			want:      PlatformOther,
+		},
+		{
+			name:      "empty user agent",
+			userAgent: "",
+			want:      PlatformOther,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DetectPlatform(tt.userAgent))
+		})
+	}
+}
+
+func TestIsCrawler(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      bool
+	}{
+		{"slack", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", true},
+		{"facebook", "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", true},
+		{"twitter", "Twitterbot/1.0", true},
+		{"whatsapp", "WhatsApp/2.23.20.0 A", true},
+		{"discord", "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)", true},
+		{"linkedin", "LinkedInBot/1.0 (compatible; Mozilla/5.0; Apache-HttpClient +http://www.linkedin.com)", true},
+		{"mobile browser", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsCrawler(tt.userAgent))
+		})
+	}
+}
+
+func TestGenerateDynamicLinkPathFromAlphabet(t *testing.T) {
+	const alphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+	counts := make(map[rune]int)
+	const iterations = 2000
+	const length = 16
+	for range iterations {
+		path := GenerateDynamicLinkPathFromAlphabet(length, alphabet)
+		assert.Len(t, path, length)
+		for _, r := range path {
+			assert.Contains(t, alphabet, string(r))
+			counts[r]++
+		}
+	}
+
+	// 256 % 31 == 8, so modulo mapping would make the first eight characters
+	// about 12% more frequent than the rest.
+	var head, tail float64
+	for i, r := range alphabet {
+		if i < 256%len(alphabet) {
+			head += float64(counts[r])
+		} else {
+			tail += float64(counts[r])
+		}
+	}
+	headMean := head / float64(256%len(alphabet))
+	tailMean := tail / float64(len(alphabet)-256%len(alphabet))
+	assert.Len(t, counts, len(alphabet))
+	assert.InEpsilon(t, tailMean, headMean, 0.05)
+}
+
+func TestValidatePathAlphabet(t *testing.T) {
+	tests := []struct {
+		name     string
+		alphabet string
+		wantErr  bool
+	}{
+		{"default", DefaultPathAlphabet, false},
+		{"unambiguous", "23456789abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ", false},
+		{"with unreserved symbols", "abc-_~", false},
+		{"too short", "a", true},
+		{"duplicate character", "abca", true},
+		{"reserved character", "abc/", true},
+		{"non ascii", "abcé", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePathAlphabet(tt.alphabet)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPathEntropyBits(t *testing.T) {
+	assert.InDelta(t, 35.73, PathEntropyBits(6, DefaultPathAlphabet), 0.01)
+	assert.InDelta(t, 40.0, PathEntropyBits(10, "abcdefghijklmnop"), 0.001)
+}
+
+func TestValidatePathLengths(t *testing.T) {
+	assert.NoError(t, ValidateShortPathLength(6))
+	assert.Error(t, ValidateShortPathLength(3))
+
+	assert.NoError(t, ValidateUnguessablePathLength(10, DefaultPathAlphabet))
+	assert.NoError(t, ValidateUnguessablePathLength(12, "abcdefghijklmnop"))
+	assert.Error(t, ValidateUnguessablePathLength(6, DefaultPathAlphabet))
+	assert.Error(t, ValidateUnguessablePathLength(12, "0123456789"))
+}
+
+func TestValidateCustomPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"letters and dashes", "spring-sale", false},
+		{"mixed", "Sale_2025", false},
+		{"empty", "", true},
+		{"too long", "abcdefghijklmnopq", true},
+		{"slash", "spring/sale", true},
+		{"space", "spring sale", true},
+		{"dot", "spring.sale", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCustomPath(tt.path, 16)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestIsBundleID(t *testing.T) {
+	assert.True(t, IsBundleID("com.example.app"))
+	assert.True(t, IsBundleID("com.example-co.App2"))
+	assert.False(t, IsBundleID(""))
+	assert.False(t, IsBundleID(".com.example"))
+	assert.False(t, IsBundleID("com..example"))
+	assert.False(t, IsBundleID("com.example_app"))
+}
+
+func TestIsURLScheme(t *testing.T) {
+	assert.True(t, IsURLScheme("myapp"))
+	assert.True(t, IsURLScheme("com.example.app+dev"))
+	assert.False(t, IsURLScheme(""))
+	assert.False(t, IsURLScheme("1app"))
+	assert.False(t, IsURLScheme("my app"))
+}
+
+func TestIsVersionString(t *testing.T) {
+	assert.True(t, IsVersionString("1"))
+	assert.True(t, IsVersionString("1.2.30"))
+	assert.False(t, IsVersionString(""))
+	assert.False(t, IsVersionString("1..2"))
+	assert.False(t, IsVersionString("v1.2"))
+}
+
+func TestIsMixedScriptHost(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want bool
+	}{
+		{"ascii", "paypal.com", false},
+		{"latin with diacritics", "münchen.example", false},
+		{"cyrillic only", "пример.рф", false},
+		{"cyrillic a in latin label", "pаypal.com", true},
+		{"cyrillic a as punycode", "xn--pypal-4ve.com", true},
+		{"greek omicron in latin label", "gοogle.com", true},
+		{"scripts in separate labels", "пример.example", false},
+		{"japanese", "日本語ひらがなカタカナ.jp", false},
+		{"han with latin", "abc中文.com", false},
+		{"digits and hyphens", "123-abc.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsMixedScriptHost(tt.host))
+		})
+	}
+}
+
+func TestSplitURLPrefix(t *testing.T) {
+	tests := []struct {
+		raw        string
+		wantHost   string
+		wantPrefix string
+		wantErr    bool
+	}{
+		{"example.com", "example.com", "", false},
+		{"https://example.com/", "example.com", "", false},
+		{"https://Example.com/links", "example.com", "links", false},
+		{"example.com/go/app/", "example.com", "go/app", false},
+		{"", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			host, prefix, err := SplitURLPrefix(tt.raw)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantHost, host)
+			assert.Equal(t, tt.wantPrefix, prefix)
+		})
+	}
+}
+
+func TestValidatePathPrefix(t *testing.T) {
+	assert.NoError(t, ValidatePathPrefix("links"))
+	assert.NoError(t, ValidatePathPrefix("go/app-1"))
+	assert.Error(t, ValidatePathPrefix(""))
+	assert.Error(t, ValidatePathPrefix("go//app"))
+	assert.Error(t, ValidatePathPrefix("/links"))
+	assert.Error(t, ValidatePathPrefix("lin ks"))
+}
+
+func TestAnonymizeIP(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected string
+	}{
+		{"203.0.113.42:51234", "203.0.113.0"},
+		{"203.0.113.42", "203.0.113.0"},
+		{"[2001:db8:abcd:12::1]:443", "2001:db8:abcd::"},
+		{"2001:db8:abcd:12::1", "2001:db8:abcd::"},
+		{"::ffff:198.51.100.7", "198.51.100.0"},
+		{"not-an-ip", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			assert.Equal(t, tt.expected, AnonymizeIP(tt.addr))
+		})
+	}
+}
+
+func TestUserAgentClass(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		expected  string
+	}{
+		{"", UserAgentUnknown},
+		{"Twitterbot/1.0", UserAgentCrawler},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) [FBAN/FBIOS;FBAV/440.0]", UserAgentInAppBrowser},
+		{"Mozilla/5.0 (Linux; Android 14) Instagram 300.0.0", UserAgentInAppBrowser},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0 Safari/537.36", UserAgentBrowser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, UserAgentClass(tt.userAgent))
+		})
+	}
+}
+
+func TestDeviceInfo(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		model     string
+		osVersion string
+	}{
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4_1 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148", "iphone", "17.4.1"},
+		{"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15", "ipad", "16.6"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/UD1A.230803.041) AppleWebKit/537.36 Chrome/120.0 Mobile", "pixel 8", "14"},
+		{"Mozilla/5.0 (Linux; Android 13; SM-S918B) AppleWebKit/537.36 Chrome/120.0 Mobile", "sm-s918b", "13"},
+		{"Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 Chrome/120.0 Mobile", "", ""},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userAgent, func(t *testing.T) {
+			model, osVersion := DeviceInfo(tt.userAgent)
+			assert.Equal(t, tt.model, model)
+			assert.Equal(t, tt.osVersion, osVersion)
+		})
+	}
+}
+
+func TestPrimaryLanguage(t *testing.T) {
+	assert.Equal(t, "en", PrimaryLanguage("en-US,en;q=0.9"))
+	assert.Equal(t, "de", PrimaryLanguage("de"))
+	assert.Equal(t, "pt", PrimaryLanguage("pt_BR"))
+	assert.Equal(t, "fr", PrimaryLanguage(" FR-ca;q=0.8"))
+	assert.Equal(t, "", PrimaryLanguage(""))
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"net/url"
+	"path"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -20,6 +21,58 @@ func IsNumericString(s string) bool {
 	return true
 }
 
+func IsBundleID(s string) bool {
+	if s == "" || strings.HasPrefix(s, ".") || strings.HasSuffix(s, ".") || strings.Contains(s, "..") {
+		return false
+	}
+
+	for _, c := range s {
+		if !isAlphanumeric(c) && c != '.' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func IsURLScheme(s string) bool {
+	if s == "" || !isLetter(rune(s[0])) {
+		return false
+	}
+
+	for _, c := range s {
+		if !isAlphanumeric(c) && c != '+' && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func IsVersionString(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, part := range strings.Split(s, ".") {
+		if part == "" || !IsNumericString(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetter(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isAlphanumeric(c rune) bool {
+	return isLetter(c) || c >= '0' && c <= '9'
+}
+
+// IsDomainAllowed reports whether rawLink matches an entry of allowList.
+// Entries are an exact host ("example.com"), any subdomain ("*.example.com"),
+// the host and its subdomains (".example.com"), optionally followed by a path
+// ("example.com/app" or "example.com/app/*"). Entries starting with "!" deny
+// matching links and take precedence over any allow entry.
 func IsDomainAllowed(allowList []string, rawLink string) bool {
 	u, err := url.Parse(rawLink)
 	if err != nil {
@@ -28,13 +81,66 @@ func IsDomainAllowed(allowList []string, rawLink string) bool {
 			Msg("Invalid link")
 		return false
 	}
-	host := strings.ToLower(u.Hostname())
+	host, err := NormalizeHost(u.Hostname())
+	if err != nil {
+		log.Error().
+			Str("raw_link", rawLink).
+			Msg("Invalid link host")
+		return false
+	}
+	linkPath := path.Clean("/" + u.Path)
+
+	allowed := false
+	for _, entry := range allowList {
+		entry = strings.TrimSpace(entry)
+		deny := strings.HasPrefix(entry, "!")
+		entry = strings.TrimPrefix(entry, "!")
+		if entry == "" || !matchesAllowEntry(entry, host, linkPath) {
+			continue
+		}
+		if deny {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+func matchesAllowEntry(entry, host, linkPath string) bool {
+	entryHost, entryPath, hasPath := strings.Cut(entry, "/")
+	wildcard := ""
+	for _, prefix := range []string{"*.", "."} {
+		if strings.HasPrefix(entryHost, prefix) {
+			wildcard, entryHost = prefix, entryHost[len(prefix):]
+			break
+		}
+	}
+	entryHost, err := NormalizeHost(entryHost)
+	if err != nil {
+		return false
+	}
+	entryHost = wildcard + entryHost
 
-	for _, allowed := range allowList {
-		allowed = strings.ToLower(strings.TrimSpace(allowed))
-		if host == allowed {
-			return true
+	switch {
+	case strings.HasPrefix(entryHost, "*."):
+		if !strings.HasSuffix(host, entryHost[1:]) {
+			return false
 		}
+	case strings.HasPrefix(entryHost, "."):
+		if host != entryHost[1:] && !strings.HasSuffix(host, entryHost) {
+			return false
+		}
+	default:
+		if host != entryHost {
+			return false
+		}
+	}
+
+	if !hasPath {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix("/"+entryPath, "/*"); ok {
+		return prefix == "" || linkPath == prefix || strings.HasPrefix(linkPath, prefix+"/")
 	}
-	return false
+	return linkPath == path.Clean("/"+entryPath)
 }